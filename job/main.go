@@ -1,19 +1,65 @@
 package main
 
 import (
+	"github.com/blend/go-sdk/exception"
+	"github.com/blend/go-sdk/graceful"
 	logger "github.com/blend/go-sdk/logger"
 	config "github.com/mat285/aqi/pkg/config"
+	"github.com/mat285/aqi/pkg/schedule"
 	"github.com/mat285/aqi/pkg/util"
 )
 
 func main() {
 	agent := logger.All()
-	conf, err := config.NewFromEnv()
+	conf, err := config.Load()
 	if err != nil {
 		agent.SyncFatalExit(err)
 	}
-	_, err = util.FetchAndSendAQIForConfig(conf, util.SanFranciscoAirVisualRequest(), agent)
+	err = conf.ValidateReports()
 	if err != nil {
 		agent.SyncFatalExit(err)
 	}
+	if conf.Daemon {
+		err = daemon(conf, agent)
+	} else {
+		err = once(conf, agent)
+	}
+	if err != nil {
+		agent.SyncFatalExit(err)
+	}
+}
+
+// once sends every configured report and exits
+func once(conf *config.Config, agent *logger.Logger) error {
+	failed := 0
+	for _, r := range conf.GetReports() {
+		agent.SyncInfof("Sending report `%s`", r.GetName())
+		if err := util.SendReport(conf, r, agent); err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return exception.New("ReportsFailed").WithMessagef("%d of %d reports failed", failed, len(conf.GetReports()))
+	}
+	return nil
+}
+
+// daemon runs the configured reports on their schedules until the process is signaled to stop
+func daemon(conf *config.Config, agent *logger.Logger) error {
+	loc, err := conf.GetScheduleLocation()
+	if err != nil {
+		return err
+	}
+	scheduler := schedule.New(agent).WithLocation(loc)
+	for _, r := range conf.GetReports() {
+		cron, err := schedule.ParseCron(r.Schedule)
+		if err != nil {
+			return err
+		}
+		report := r
+		scheduler.Add(report.GetName(), cron, func() error {
+			return util.SendReport(conf, report, agent)
+		})
+	}
+	return graceful.Shutdown(scheduler)
 }
@@ -0,0 +1,72 @@
+package airnow
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	exception "github.com/blend/go-sdk/exception"
+	request "github.com/blend/go-sdk/request"
+)
+
+// Client is an airnow client
+type Client struct {
+	apiKey string
+}
+
+// New returns a new airnow client
+func New(apiKey string) *Client {
+	return &Client{
+		apiKey: apiKey,
+	}
+}
+
+// LatLong returns the current observations for the reporting area nearest the coordinates
+func (c *Client) LatLong(lat, lon float64) ([]Observation, error) {
+	v := url.Values{}
+	v.Set("latitude", strconv.FormatFloat(lat, 'f', -1, 64))
+	v.Set("longitude", strconv.FormatFloat(lon, 'f', -1, 64))
+	return c.observations(LatLongURL, v)
+}
+
+// ZipCode returns the current observations for the reporting area of the zip code
+func (c *Client) ZipCode(zip string) ([]Observation, error) {
+	if len(zip) == 0 {
+		return nil, exception.New("MissingZipCode")
+	}
+	v := url.Values{}
+	v.Set("zipCode", zip)
+	return c.observations(ZipCodeURL, v)
+}
+
+func (c *Client) observations(base string, v url.Values) ([]Observation, error) {
+	u, _ := url.Parse(base)
+	v.Set("format", "application/json")
+	v.Set("distance", strconv.Itoa(DefaultDistance))
+	v.Set("API_KEY", c.apiKey)
+	u.RawQuery = v.Encode()
+	obs := []Observation{}
+	meta, err := request.Get(u.String()).JSONWithMeta(&obs)
+	if meta != nil && meta.StatusCode != http.StatusOK {
+		return nil, exception.New("RequestFailed").WithMessagef("status code %d", meta.StatusCode)
+	} else if err != nil {
+		return nil, err
+	}
+	return obs, nil
+}
+
+// Time returns the time of the observation, airnow reports the local hour with the abbreviation of a us timezone
+func (o Observation) Time() (time.Time, error) {
+	offset, ok := timezoneOffsets[strings.ToUpper(strings.TrimSpace(o.LocalTimeZone))]
+	if !ok {
+		return time.Time{}, exception.New(ErrUnknownTimezone).WithMessage(o.LocalTimeZone)
+	}
+	loc := time.FixedZone(o.LocalTimeZone, int(offset/time.Second))
+	t, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(o.DateObserved), loc)
+	if err != nil {
+		return time.Time{}, exception.New(err)
+	}
+	return t.Add(time.Duration(o.HourObserved) * time.Hour), nil
+}
@@ -0,0 +1,67 @@
+package airnow
+
+import (
+	"time"
+
+	exception "github.com/blend/go-sdk/exception"
+)
+
+const (
+	// BaseURL is the base url for requests
+	BaseURL = "https://www.airnowapi.org/aq/"
+	// LatLongURL is the url for current observations by coordinates
+	LatLongURL = BaseURL + "observation/latLong/current/"
+	// ZipCodeURL is the url for current observations by zip code
+	ZipCodeURL = BaseURL + "observation/zipCode/current/"
+
+	// DefaultDistance is the default distance in miles to search for a reporting area
+	DefaultDistance = 25
+)
+
+// ErrUnknownTimezone is returned when an observation is in a timezone without a known offset
+const ErrUnknownTimezone exception.Class = "UnknownTimezone"
+
+// timezoneOffsets are the utc offsets of the timezones airnow reports observations in
+var timezoneOffsets = map[string]time.Duration{
+	"AST":  -4 * time.Hour,
+	"EST":  -5 * time.Hour,
+	"EDT":  -4 * time.Hour,
+	"CST":  -6 * time.Hour,
+	"CDT":  -5 * time.Hour,
+	"MST":  -7 * time.Hour,
+	"MDT":  -6 * time.Hour,
+	"PST":  -8 * time.Hour,
+	"PDT":  -7 * time.Hour,
+	"AKST": -9 * time.Hour,
+	"AKDT": -8 * time.Hour,
+	"HST":  -10 * time.Hour,
+}
+
+const (
+	// ParameterPM25 is the parameter name for pm2.5
+	ParameterPM25 = "PM2.5"
+	// ParameterPM10 is the parameter name for pm10
+	ParameterPM10 = "PM10"
+	// ParameterO3 is the parameter name for ozone
+	ParameterO3 = "O3"
+)
+
+// Observation is the current observation of a single parameter for a reporting area
+type Observation struct {
+	DateObserved  string   `json:"DateObserved"`
+	HourObserved  int      `json:"HourObserved"`
+	LocalTimeZone string   `json:"LocalTimeZone"`
+	ReportingArea string   `json:"ReportingArea"`
+	StateCode     string   `json:"StateCode"`
+	Latitude      float64  `json:"Latitude"`
+	Longitude     float64  `json:"Longitude"`
+	ParameterName string   `json:"ParameterName"`
+	AQI           int      `json:"AQI"`
+	Category      Category `json:"Category"`
+}
+
+// Category is the epa category of an observation
+type Category struct {
+	Number int    `json:"Number"`
+	Name   string `json:"Name"`
+}
@@ -0,0 +1,40 @@
+package airnow
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestObservations(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/observations.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	obs := []Observation{}
+	if err := json.Unmarshal(data, &obs); err != nil {
+		t.Fatal(err)
+	}
+	if len(obs) != 3 {
+		t.Fatalf("expected 3 observations, got %d", len(obs))
+	}
+	o := obs[1]
+	if o.ParameterName != ParameterPM25 || o.AQI != 151 || o.Category.Number != 4 || o.ReportingArea != "San Francisco" || o.StateCode != "CA" {
+		t.Errorf("unexpected observation %+v", o)
+	}
+	observed, err := o.Time()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := time.Date(2020, time.September, 7, 20, 0, 0, 0, time.UTC); !observed.Equal(expected) {
+		t.Errorf("observed at %s, expected %s", observed.UTC(), expected)
+	}
+}
+
+func TestObservationTimeUnknownTimezone(t *testing.T) {
+	o := Observation{DateObserved: "2020-09-07", HourObserved: 12, LocalTimeZone: "XST"}
+	if _, err := o.Time(); err == nil {
+		t.Error("expected an error for an unknown timezone")
+	}
+}
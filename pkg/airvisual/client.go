@@ -1,15 +1,23 @@
 package airvisual
 
 import (
+	"encoding/json"
 	"net/url"
+	"strconv"
 
 	exception "github.com/blend/go-sdk/exception"
 	request "github.com/blend/go-sdk/request"
 )
 
+// Limiter limits the calls a client makes, returning an error when a call is not allowed
+type Limiter interface {
+	Take() error
+}
+
 // Client is an airvisual client
 type Client struct {
-	apiKey string
+	apiKey  string
+	limiter Limiter
 }
 
 // New returns a new airvisual client
@@ -19,23 +27,121 @@ func New(apiKey string) *Client {
 	}
 }
 
+// WithLimiter sets the limiter every call is taken from
+func (c *Client) WithLimiter(l Limiter) *Client {
+	c.limiter = l
+	return c
+}
+
 // Location returns the data for a location
 func (c *Client) Location(r *LocationRequest) (*Response, error) {
 	err := r.Validate()
 	if err != nil {
 		return nil, err
 	}
-	req := request.Get(c.locationRequestURL(r).String())
 	resp := &Response{}
-	return resp, req.JSON(resp)
+	return resp, c.get(c.locationRequestURL(r), resp)
+}
+
+// NearestCity returns the data for the city nearest to the coordinates
+func (c *Client) NearestCity(lat, lon float64) (*Response, error) {
+	err := ValidateCoordinates(lat, lon)
+	if err != nil {
+		return nil, err
+	}
+	resp := &Response{}
+	return resp, c.get(c.coordinatesRequestURL(NearestCityURL, lat, lon), resp)
+}
+
+// NearestCityByIP returns the data for the city nearest to the ip the request is made from
+func (c *Client) NearestCityByIP() (*Response, error) {
+	resp := &Response{}
+	return resp, c.get(c.requestURL(NearestCityURL, url.Values{}), resp)
+}
+
+// Station returns the data for a station
+func (c *Client) Station(r *StationRequest) (*Response, error) {
+	err := r.Validate()
+	if err != nil {
+		return nil, err
+	}
+	resp := &Response{}
+	return resp, c.get(c.stationRequestURL(r), resp)
+}
+
+// NearestStation returns the data for the station nearest to the coordinates
+func (c *Client) NearestStation(lat, lon float64) (*Response, error) {
+	err := ValidateCoordinates(lat, lon)
+	if err != nil {
+		return nil, err
+	}
+	resp := &Response{}
+	return resp, c.get(c.coordinatesRequestURL(NearestStationURL, lat, lon), resp)
+}
+
+// NearestStationByIP returns the data for the station nearest to the ip the request is made from
+func (c *Client) NearestStationByIP() (*Response, error) {
+	resp := &Response{}
+	return resp, c.get(c.requestURL(NearestStationURL, url.Values{}), resp)
+}
+
+// Countries returns the supported countries
+func (c *Client) Countries() (*ListResponse, error) {
+	resp := &ListResponse{}
+	return resp, c.get(c.requestURL(CountriesURL, url.Values{}), resp)
+}
+
+// States returns the supported states in the country
+func (c *Client) States(country string) (*ListResponse, error) {
+	if len(country) == 0 {
+		return nil, exception.New("MissingCountry")
+	}
+	v := url.Values{}
+	v.Set("country", country)
+	resp := &ListResponse{}
+	return resp, c.get(c.requestURL(StatesURL, v), resp)
+}
+
+// Cities returns the supported cities in the state and country
+func (c *Client) Cities(state, country string) (*ListResponse, error) {
+	if len(state) == 0 {
+		return nil, exception.New("MissingState")
+	} else if len(country) == 0 {
+		return nil, exception.New("MissingCountry")
+	}
+	v := url.Values{}
+	v.Set("state", state)
+	v.Set("country", country)
+	resp := &ListResponse{}
+	return resp, c.get(c.requestURL(CitiesURL, v), resp)
 }
 
 func (c *Client) locationRequestURL(r *LocationRequest) *url.URL {
-	u, _ := url.Parse(CityURL)
-	v := u.Query()
+	v := url.Values{}
 	v.Set("city", r.City)
 	v.Set("state", r.State)
 	v.Set("country", r.Country)
+	return c.requestURL(CityURL, v)
+}
+
+func (c *Client) stationRequestURL(r *StationRequest) *url.URL {
+	v := url.Values{}
+	v.Set("station", r.Station)
+	v.Set("city", r.City)
+	v.Set("state", r.State)
+	v.Set("country", r.Country)
+	return c.requestURL(StationURL, v)
+}
+
+func (c *Client) coordinatesRequestURL(base string, lat, lon float64) *url.URL {
+	v := url.Values{}
+	v.Set("lat", strconv.FormatFloat(lat, 'f', -1, 64))
+	v.Set("lon", strconv.FormatFloat(lon, 'f', -1, 64))
+	return c.requestURL(base, v)
+}
+
+func (c *Client) requestURL(base string, v url.Values) *url.URL {
+	u, _ := url.Parse(base)
 	v.Set("key", c.apiKey)
 	u.RawQuery = v.Encode()
 	return u
@@ -54,3 +160,49 @@ func (r *LocationRequest) Validate() error {
 	}
 	return nil
 }
+
+// Validate validates the station request
+func (r *StationRequest) Validate() error {
+	if r == nil {
+		return exception.New("NilRequest")
+	} else if len(r.Station) == 0 {
+		return exception.New("MissingStation")
+	} else if len(r.City) == 0 {
+		return exception.New("MissingCity")
+	} else if len(r.State) == 0 {
+		return exception.New("MissingState")
+	} else if len(r.Country) == 0 {
+		return exception.New("MissingCountry")
+	}
+	return nil
+}
+
+// ValidateCoordinates validates the latitude and longitude
+func ValidateCoordinates(lat, lon float64) error {
+	if lat < -90 || lat > 90 {
+		return exception.New("InvalidLatitude").WithMessagef("%v", lat)
+	} else if lon < -180 || lon > 180 {
+		return exception.New("InvalidLongitude").WithMessagef("%v", lon)
+	}
+	return nil
+}
+
+// get takes a call from the limiter and gets the url into the response,
+// returning the typed error for the failure if the request failed
+func (c *Client) get(u *url.URL, resp interface{}) error {
+	if c.limiter != nil {
+		err := c.limiter.Take()
+		if err != nil {
+			return err
+		}
+	}
+	body, err := request.Get(u.String()).Bytes()
+	if err != nil {
+		return err
+	}
+	err = ResponseError(body)
+	if err != nil {
+		return err
+	}
+	return exception.New(json.Unmarshal(body, resp))
+}
@@ -0,0 +1,89 @@
+package airvisual
+
+import (
+	"encoding/json"
+
+	exception "github.com/blend/go-sdk/exception"
+)
+
+const (
+	// ErrCityNotFound is returned when the city, state or country is not supported
+	ErrCityNotFound exception.Class = "city_not_found"
+	// ErrNoNearestStation is returned when there is no station near the coordinates
+	ErrNoNearestStation exception.Class = "no_nearest_station"
+	// ErrIncorrectAPIKey is returned when the api key is wrong
+	ErrIncorrectAPIKey exception.Class = "incorrect_api_key"
+	// ErrAPIKeyExpired is returned when the api key has expired
+	ErrAPIKeyExpired exception.Class = "api_key_expired"
+	// ErrCallLimitReached is returned when the api key has used up its calls
+	ErrCallLimitReached exception.Class = "call_limit_reached"
+	// ErrTooManyRequests is returned when calls are made too quickly
+	ErrTooManyRequests exception.Class = "too_many_requests"
+	// ErrPermissionDenied is returned when the api key's plan does not include the endpoint
+	ErrPermissionDenied exception.Class = "permission_denied"
+	// ErrFeatureNotAvailable is returned when the api key's plan does not include the feature
+	ErrFeatureNotAvailable exception.Class = "feature_not_available"
+	// ErrRequestFailed is returned for any other failure
+	ErrRequestFailed exception.Class = "RequestFailed"
+)
+
+// Errors are the known error classes by the message airvisual fails with
+var Errors = map[string]exception.Class{}
+
+func init() {
+	for _, class := range []exception.Class{
+		ErrCityNotFound,
+		ErrNoNearestStation,
+		ErrIncorrectAPIKey,
+		ErrAPIKeyExpired,
+		ErrCallLimitReached,
+		ErrTooManyRequests,
+		ErrPermissionDenied,
+		ErrFeatureNotAvailable,
+	} {
+		Errors[string(class)] = class
+	}
+}
+
+// failure is the body of a response when the request fails
+type failure struct {
+	Status Status          `json:"status"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// failureData is the data of a failed response
+type failureData struct {
+	Message string `json:"message"`
+}
+
+// ResponseError returns the typed error for the body of a response, or nil if the request succeeded
+func ResponseError(body []byte) error {
+	f := &failure{}
+	err := json.Unmarshal(body, f)
+	if err != nil {
+		return exception.New(err)
+	}
+	if f.Status == StatusSuccess {
+		return nil
+	}
+	data := &failureData{}
+	if json.Unmarshal(f.Data, data) != nil || len(data.Message) == 0 {
+		return exception.New(ErrRequestFailed).WithMessagef("status %s", f.Status)
+	}
+	if class, ok := Errors[data.Message]; ok {
+		return exception.New(class)
+	}
+	return exception.New(ErrRequestFailed).WithMessage(data.Message)
+}
+
+// ErrorClass returns the class of the airvisual error in the error or nested in it, if there is one
+func ErrorClass(err error) (exception.Class, bool) {
+	for ; err != nil; err = exception.Inner(err) {
+		for _, class := range Errors {
+			if exception.Is(err, class) {
+				return class, true
+			}
+		}
+	}
+	return "", false
+}
@@ -9,7 +9,7 @@ const (
 	// StatusSuccess is the sucess status
 	StatusSuccess Status = "success"
 	// StatusFailed is the failed status
-	StatusFailed Status = "failed"
+	StatusFailed Status = "fail"
 )
 
 const (
@@ -17,6 +17,18 @@ const (
 	BaseURL = "https://api.airvisual.com/v2/"
 	// CityURL is the url for city requests
 	CityURL = BaseURL + "city"
+	// NearestCityURL is the url for nearest city requests
+	NearestCityURL = BaseURL + "nearest_city"
+	// StationURL is the url for station requests
+	StationURL = BaseURL + "station"
+	// NearestStationURL is the url for nearest station requests
+	NearestStationURL = BaseURL + "nearest_station"
+	// CountriesURL is the url for listing supported countries
+	CountriesURL = BaseURL + "countries"
+	// StatesURL is the url for listing supported states in a country
+	StatesURL = BaseURL + "states"
+	// CitiesURL is the url for listing supported cities in a state
+	CitiesURL = BaseURL + "cities"
 )
 
 // LocationRequest is a request for a location data
@@ -26,18 +38,104 @@ type LocationRequest struct {
 	Country string
 }
 
+// StationRequest is a request for a station's data
+type StationRequest struct {
+	Station string
+	City    string
+	State   string
+	Country string
+}
+
 // Response is a response from air visual
 type Response struct {
 	Status Status `json:"status"`
 	Data   Data   `json:"data"`
 }
 
+// ListResponse is a response listing supported locations
+type ListResponse struct {
+	Status Status     `json:"status"`
+	Data   []ListItem `json:"data"`
+}
+
+// ListItem is a single supported location, only one field is set depending on the list
+type ListItem struct {
+	Country string `json:"country,omitempty"`
+	State   string `json:"state,omitempty"`
+	City    string `json:"city,omitempty"`
+}
+
+// Names returns the names of the listed locations
+func (r *ListResponse) Names() []string {
+	names := make([]string, 0, len(r.Data))
+	for _, item := range r.Data {
+		if len(item.City) > 0 {
+			names = append(names, item.City)
+		} else if len(item.State) > 0 {
+			names = append(names, item.State)
+		} else if len(item.Country) > 0 {
+			names = append(names, item.Country)
+		}
+	}
+	return names
+}
+
 // Data is the payload of a response
 type Data struct {
-	City    string `json:"city"`
-	State   string `json:"state"`
-	Country string `json:"country"`
-	Current Air    `json:"current"`
+	Name           string     `json:"name,omitempty"`
+	City           string     `json:"city"`
+	State          string     `json:"state"`
+	Country        string     `json:"country"`
+	Location       Location   `json:"location"`
+	Current        Air        `json:"current"`
+	Forecasts      []Forecast `json:"forecasts,omitempty"`
+	DailyForecasts []Forecast `json:"forecasts_daily,omitempty"`
+	History        History    `json:"history"`
+
+	// Fetched is when the data was fetched from the provider, set when it may have come from a cache
+	Fetched time.Time `json:"-"`
+}
+
+// Forecast is a predicted reading, hourly or daily depending on the list it is in
+type Forecast struct {
+	Time           time.Time `json:"ts"`
+	AQI            int       `json:"aqius"`
+	AQICN          int       `json:"aqicn"`
+	Temperature    int       `json:"tp"`
+	TemperatureMin int       `json:"tp_min"`
+	Pressure       int       `json:"pr"`
+	Humidity       int       `json:"hu"`
+	WindDirection  int       `json:"wd"`
+	WindSpeed      float32   `json:"ws"`
+	Icon           string    `json:"ic"`
+}
+
+// History is the past readings for a location
+type History struct {
+	Pollution []Pollution `json:"pollution,omitempty"`
+	Weather   []Weather   `json:"weather,omitempty"`
+}
+
+// Location is the geo location of the data
+type Location struct {
+	Type        string    `json:"type"`
+	Coordinates []float64 `json:"coordinates"`
+}
+
+// Latitude returns the latitude of the location
+func (l Location) Latitude() float64 {
+	if len(l.Coordinates) < 2 {
+		return 0
+	}
+	return l.Coordinates[1]
+}
+
+// Longitude returns the longitude of the location
+func (l Location) Longitude() float64 {
+	if len(l.Coordinates) < 1 {
+		return 0
+	}
+	return l.Coordinates[0]
 }
 
 // Air is the data about the air
@@ -46,10 +144,83 @@ type Air struct {
 	Weather   Weather   `json:"weather"`
 }
 
+// Pollutant is the code for a pollutant
+type Pollutant string
+
+const (
+	// PollutantPM25 is fine particulate matter under 2.5 microns
+	PollutantPM25 Pollutant = "p2"
+	// PollutantPM10 is particulate matter under 10 microns
+	PollutantPM10 Pollutant = "p1"
+	// PollutantO3 is ozone
+	PollutantO3 Pollutant = "o3"
+	// PollutantNO2 is nitrogen dioxide
+	PollutantNO2 Pollutant = "n2"
+	// PollutantSO2 is sulfur dioxide
+	PollutantSO2 Pollutant = "s2"
+	// PollutantCO is carbon monoxide
+	PollutantCO Pollutant = "co"
+)
+
+// Name returns the display name of the pollutant
+func (p Pollutant) Name() string {
+	switch p {
+	case PollutantPM25:
+		return "PM2.5"
+	case PollutantPM10:
+		return "PM10"
+	case PollutantO3:
+		return "Ozone"
+	case PollutantNO2:
+		return "NO2"
+	case PollutantSO2:
+		return "SO2"
+	case PollutantCO:
+		return "CO"
+	}
+	return string(p)
+}
+
 // Pollution is pollution data
 type Pollution struct {
-	Time time.Time `json:"ts"`
-	AQI  int       `json:"aqius"`
+	Time            time.Time      `json:"ts"`
+	AQI             int            `json:"aqius"`
+	MainPollutant   Pollutant      `json:"mainus"`
+	AQICN           int            `json:"aqicn"`
+	MainPollutantCN Pollutant      `json:"maincn"`
+	PM25            *Concentration `json:"p2,omitempty"`
+	PM10            *Concentration `json:"p1,omitempty"`
+	O3              *Concentration `json:"o3,omitempty"`
+	NO2             *Concentration `json:"n2,omitempty"`
+	SO2             *Concentration `json:"s2,omitempty"`
+	CO              *Concentration `json:"co,omitempty"`
+}
+
+// Concentration returns the concentration of the pollutant, or nil if it was not measured
+func (p Pollution) Concentration(pollutant Pollutant) *Concentration {
+	switch pollutant {
+	case PollutantPM25:
+		return p.PM25
+	case PollutantPM10:
+		return p.PM10
+	case PollutantO3:
+		return p.O3
+	case PollutantNO2:
+		return p.NO2
+	case PollutantSO2:
+		return p.SO2
+	case PollutantCO:
+		return p.CO
+	}
+	return nil
+}
+
+// Concentration is the measured concentration of a single pollutant
+// Units are ug/m3 for particulates and ppb for gases, except co which is ppm
+type Concentration struct {
+	Value float64 `json:"conc"`
+	AQI   int     `json:"aqius"`
+	AQICN int     `json:"aqicn"`
 }
 
 // Weather is the weather data
@@ -57,6 +228,8 @@ type Weather struct {
 	Time          time.Time `json:"ts"`
 	Humidity      int       `json:"hu"`
 	Temperature   int       `json:"tp"`
+	Pressure      int       `json:"pr"`
 	WindDirection int       `json:"wd"`
 	WindSpeed     float32   `json:"ws"`
+	Icon          string    `json:"ic"`
 }
@@ -0,0 +1,38 @@
+package airvisual
+
+const (
+	// MolarVolume is the volume in liters of a mole of gas at 25C and 1 atm
+	MolarVolume = 24.45
+)
+
+// MolecularWeight returns the molecular weight in g/mol of a gas pollutant, or 0 for particulates
+func (p Pollutant) MolecularWeight() float64 {
+	switch p {
+	case PollutantO3:
+		return 48.00
+	case PollutantNO2:
+		return 46.01
+	case PollutantSO2:
+		return 64.07
+	case PollutantCO:
+		return 28.01
+	}
+	return 0
+}
+
+// IsGas returns if the pollutant is a gas, whose concentration is reported by volume
+func (p Pollutant) IsGas() bool {
+	return p.MolecularWeight() > 0
+}
+
+// MicrogramsPerCubicMeter converts a concentration in the units airvisual reports the pollutant in to ug/m3
+func MicrogramsPerCubicMeter(p Pollutant, conc float64) float64 {
+	if !p.IsGas() {
+		return conc
+	}
+	ppb := conc
+	if p == PollutantCO {
+		ppb = conc * 1000
+	}
+	return ppb * p.MolecularWeight() / MolarVolume
+}
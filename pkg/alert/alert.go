@@ -0,0 +1,52 @@
+package alert
+
+import (
+	"sort"
+)
+
+// Thresholds are ascending aqi thresholds that raise the alert level as they are crossed.
+// Levels rise as soon as the aqi is above a threshold, but only fall once the aqi drops
+// below the threshold by the hysteresis so readings bouncing around a threshold don't repeat alerts.
+type Thresholds struct {
+	Values     []int
+	Hysteresis int
+}
+
+// NewThresholds returns thresholds with the values sorted
+func NewThresholds(hysteresis int, values ...int) Thresholds {
+	sorted := append([]int{}, values...)
+	sort.Ints(sorted)
+	return Thresholds{Values: sorted, Hysteresis: hysteresis}
+}
+
+// Level returns the alert level for the aqi given the previous level, 0 is below every threshold
+func (t Thresholds) Level(previous, aqi int) int {
+	level := 0
+	for i, v := range t.Values {
+		if aqi > v {
+			level = i + 1
+		}
+	}
+	if previous > len(t.Values) {
+		previous = len(t.Values)
+	}
+	for l := previous; l > level; l-- {
+		if aqi >= t.ClearValue(l) {
+			return l
+		}
+	}
+	return level
+}
+
+// RaiseValue returns the aqi that must be exceeded to reach the level
+func (t Thresholds) RaiseValue(level int) int {
+	if level <= 0 || level > len(t.Values) {
+		return 0
+	}
+	return t.Values[level-1]
+}
+
+// ClearValue returns the aqi that must be dropped below to fall from the level
+func (t Thresholds) ClearValue(level int) int {
+	return t.RaiseValue(level) - t.Hysteresis
+}
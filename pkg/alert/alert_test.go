@@ -0,0 +1,53 @@
+package alert
+
+import "testing"
+
+func TestLevel(t *testing.T) {
+	thresholds := NewThresholds(20, 150, 100)
+	cases := []struct {
+		name     string
+		previous int
+		aqi      int
+		expected int
+	}{
+		{"below every threshold", 0, 80, 0},
+		{"at a threshold", 0, 100, 0},
+		{"crossing a threshold", 0, 101, 1},
+		{"crossing two thresholds", 0, 180, 2},
+		{"within the hysteresis band", 1, 90, 1},
+		{"at the bottom of the band", 1, 80, 1},
+		{"recovering below the band", 1, 79, 0},
+		{"falling into the lower band", 2, 120, 1},
+		{"holding in the upper band", 2, 140, 2},
+		{"recovering from the top", 2, 50, 0},
+		{"previous level beyond the thresholds", 5, 140, 2},
+	}
+	for _, c := range cases {
+		if level := thresholds.Level(c.previous, c.aqi); level != c.expected {
+			t.Errorf("%s: level %d from %d at %d, expected %d", c.name, level, c.previous, c.aqi, c.expected)
+		}
+	}
+}
+
+func TestLevelWithoutHysteresis(t *testing.T) {
+	thresholds := NewThresholds(0, 100)
+	if level := thresholds.Level(1, 100); level != 1 {
+		t.Errorf("expected the level to hold at the threshold, got %d", level)
+	}
+	if level := thresholds.Level(1, 99); level != 0 {
+		t.Errorf("expected the level to clear as soon as the aqi drops below the threshold, got %d", level)
+	}
+}
+
+func TestRaiseAndClearValue(t *testing.T) {
+	thresholds := NewThresholds(20, 150, 100)
+	if v := thresholds.RaiseValue(1); v != 100 {
+		t.Errorf("expected the thresholds to be sorted, raise value %d", v)
+	}
+	if v := thresholds.ClearValue(2); v != 130 {
+		t.Errorf("expected the clear value to be the threshold less the hysteresis, got %d", v)
+	}
+	if v := thresholds.RaiseValue(0); v != 0 {
+		t.Errorf("expected no raise value below the first level, got %d", v)
+	}
+}
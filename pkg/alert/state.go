@@ -0,0 +1,69 @@
+package alert
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	exception "github.com/blend/go-sdk/exception"
+	"github.com/mat285/aqi/pkg/fileutil"
+)
+
+// State is the last alerted state of a location
+type State struct {
+	Level int       `json:"level"`
+	AQI   int       `json:"aqi"`
+	Time  time.Time `json:"time"`
+}
+
+// Store persists the states of locations to a json file between runs
+type Store struct {
+	path   string
+	States map[string]State `json:"states"`
+}
+
+// Load loads the store from the file, a missing file is an empty store
+func Load(path string) (*Store, error) {
+	s := &Store{path: path, States: map[string]State{}}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	} else if err != nil {
+		return nil, exception.New(err)
+	}
+	err = json.Unmarshal(data, s)
+	if err != nil {
+		return nil, exception.New(err)
+	}
+	if s.States == nil {
+		s.States = map[string]State{}
+	}
+	return s, nil
+}
+
+// Get returns the state for the location and if there was one
+func (s *Store) Get(key string) (State, bool) {
+	state, ok := s.States[Key(key)]
+	return state, ok
+}
+
+// Set sets the state for the location
+func (s *Store) Set(key string, state State) {
+	s.States[Key(key)] = state
+}
+
+// Save writes the store to its file, replacing it atomically
+func (s *Store) Save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return exception.New(err)
+	}
+	return fileutil.WriteAtomic(s.path, data)
+}
+
+// Key normalizes a location key
+func Key(key string) string {
+	return strings.ToLower(strings.TrimSpace(key))
+}
@@ -0,0 +1,124 @@
+package alias
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	exception "github.com/blend/go-sdk/exception"
+	"github.com/mat285/aqi/pkg/fileutil"
+)
+
+// Store persists the location aliases added at runtime to a json file
+type Store struct {
+	lock     sync.Mutex
+	path     string
+	modified time.Time
+	size     int64
+	Aliases  map[string]string `json:"aliases"`
+}
+
+// Load loads the store from the file, a missing file is an empty store
+func Load(path string) (*Store, error) {
+	s := &Store{path: path, Aliases: map[string]string{}}
+	err := s.Refresh()
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Refresh reloads the store if its file's modification time or size changed since it was last read or written,
+// so aliases changed by another process are seen without restarting
+func (s *Store) Refresh() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	info, err := os.Stat(s.path)
+	if os.IsNotExist(err) {
+		s.Aliases, s.modified, s.size = map[string]string{}, time.Time{}, 0
+		return nil
+	} else if err != nil {
+		return exception.New(err)
+	}
+	if info.ModTime().Equal(s.modified) && info.Size() == s.size {
+		return nil
+	}
+	data, err := ioutil.ReadFile(s.path)
+	if err != nil {
+		return exception.New(err)
+	}
+	loaded := struct {
+		Aliases map[string]string `json:"aliases"`
+	}{}
+	err = json.Unmarshal(data, &loaded)
+	if err != nil {
+		return exception.New(err)
+	}
+	if loaded.Aliases == nil {
+		loaded.Aliases = map[string]string{}
+	}
+	s.Aliases, s.modified, s.size = loaded.Aliases, info.ModTime(), info.Size()
+	return nil
+}
+
+// Get returns the location the alias names and if there was one
+func (s *Store) Get(name string) (string, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	location, ok := s.Aliases[Key(name)]
+	return location, ok
+}
+
+// All returns a copy of every alias
+func (s *Store) All() map[string]string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	ret := make(map[string]string, len(s.Aliases))
+	for name, location := range s.Aliases {
+		ret[name] = location
+	}
+	return ret
+}
+
+// Set sets the location the alias names and saves the store
+func (s *Store) Set(name, location string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.Aliases[Key(name)] = location
+	return s.save()
+}
+
+// Remove removes the alias and saves the store, returning if there was one
+func (s *Store) Remove(name string) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, ok := s.Aliases[Key(name)]; !ok {
+		return false, nil
+	}
+	delete(s.Aliases, Key(name))
+	return true, s.save()
+}
+
+// save writes the store to its file, replacing it atomically
+func (s *Store) save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return exception.New(err)
+	}
+	err = fileutil.WriteAtomic(s.path, data)
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(s.path); err == nil {
+		s.modified, s.size = info.ModTime(), info.Size()
+	}
+	return nil
+}
+
+// Key normalizes an alias name
+func Key(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), " ")
+}
@@ -0,0 +1,116 @@
+package alias
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func tempPath(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "alias")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Join(dir, "aliases.json"), func() { os.RemoveAll(dir) }
+}
+
+func TestSetAndRemove(t *testing.T) {
+	path, done := tempPath(t)
+	defer done()
+	s, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.All()) != 0 {
+		t.Errorf("expected a missing file to be an empty store, got %v", s.All())
+	}
+	if err := s.Set("  The   Office ", "94110"); err != nil {
+		t.Fatal(err)
+	}
+	if location, ok := s.Get("the office"); !ok || location != "94110" {
+		t.Errorf("expected the alias by its normalized name, got %q %v", location, ok)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if location, ok := loaded.Get("THE OFFICE"); !ok || location != "94110" {
+		t.Errorf("expected the alias to be saved, got %q %v", location, ok)
+	}
+
+	removed, err := s.Remove("the office")
+	if err != nil || !removed {
+		t.Errorf("expected the alias to be removed, got %v %v", removed, err)
+	}
+	removed, err = s.Remove("the office")
+	if err != nil || removed {
+		t.Errorf("expected no alias to remove, got %v %v", removed, err)
+	}
+	loaded, err = Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := loaded.Get("the office"); ok {
+		t.Error("expected the removal to be saved")
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	path, done := tempPath(t)
+	defer done()
+	s, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set("cabin", "39.1,-120.1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := other.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	if location, ok := other.Get("cabin"); !ok || location != "39.1,-120.1" {
+		t.Errorf("expected another writer's alias after a refresh, got %q %v", location, ok)
+	}
+
+	if err := other.Set("cabin", "tahoe"); err != nil {
+		t.Fatal(err)
+	}
+	// the modification time may not change within the filesystem's resolution, the size does
+	if err := s.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	if location, _ := s.Get("cabin"); location != "tahoe" {
+		t.Errorf("expected another writer's change after a refresh, got %q", location)
+	}
+
+	later := time.Now().Add(time.Minute)
+	if err := ioutil.WriteFile(path, []byte(`{"aliases": {"home": "sf"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.Get("cabin"); ok {
+		t.Error("expected an alias removed by another writer to be gone after a refresh")
+	}
+	if location, _ := s.Get("home"); location != "sf" {
+		t.Errorf("expected the rewritten file's aliases, got %v", s.All())
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Refresh(); err != nil || len(s.All()) != 0 {
+		t.Errorf("expected a deleted file to empty the store, got %v %v", s.All(), err)
+	}
+}
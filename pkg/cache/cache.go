@@ -0,0 +1,142 @@
+package cache
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	exception "github.com/blend/go-sdk/exception"
+	"github.com/mat285/aqi/pkg/airvisual"
+	"github.com/mat285/aqi/pkg/fileutil"
+)
+
+const (
+	// UpdateInterval is how often stations publish a new reading
+	UpdateInterval = time.Hour
+	// MinTTL is the least time an entry is kept even if a newer reading is already expected
+	MinTTL = 5 * time.Minute
+)
+
+// Entry is cached air data and when it was fetched
+type Entry struct {
+	Data    *airvisual.Data `json:"data"`
+	Fetched time.Time       `json:"fetched"`
+}
+
+// Age returns how long ago the entry was fetched
+func (e *Entry) Age(now time.Time) time.Duration {
+	return now.Sub(e.Fetched)
+}
+
+// Expires returns when the entry expires, after the ttl or once a newer reading is expected, whichever is first,
+// but never before the minimum ttl
+func (e *Entry) Expires(ttl time.Duration) time.Time {
+	expires := e.Fetched.Add(ttl)
+	if e.Data == nil || e.Data.Current.Pollution.Time.IsZero() {
+		return expires
+	}
+	next := e.Data.Current.Pollution.Time.Add(UpdateInterval)
+	if next.Before(expires) {
+		expires = next
+	}
+	if min := e.Fetched.Add(MinTTL); expires.Before(min) && ttl > MinTTL {
+		expires = min
+	}
+	return expires
+}
+
+// Cache is a ttl cache of air data keyed by location, optionally persisted to a json file
+type Cache struct {
+	sync.Mutex
+	TTL     time.Duration `json:"-"`
+	path    string
+	Entries map[string]*Entry `json:"entries"`
+}
+
+// New returns a new in memory cache
+func New(ttl time.Duration) *Cache {
+	return &Cache{TTL: ttl, Entries: map[string]*Entry{}}
+}
+
+// Load loads the cache persisted to the file, a missing file is an empty cache
+func Load(path string, ttl time.Duration) (*Cache, error) {
+	c := New(ttl)
+	c.path = path
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return c, nil
+	} else if err != nil {
+		return nil, exception.New(err)
+	}
+	err = json.Unmarshal(data, c)
+	if err != nil {
+		return nil, exception.New(err)
+	}
+	if c.Entries == nil {
+		c.Entries = map[string]*Entry{}
+	}
+	return c, nil
+}
+
+// Get returns the entry for the key if it has not expired
+func (c *Cache) Get(key string, now time.Time) (*Entry, bool) {
+	c.Lock()
+	defer c.Unlock()
+	e, ok := c.Entries[key]
+	if !ok || !now.Before(e.Expires(c.TTL)) {
+		return nil, false
+	}
+	return e, true
+}
+
+// Last returns the entry for the key even if it has expired
+func (c *Cache) Last(key string) (*Entry, bool) {
+	c.Lock()
+	defer c.Unlock()
+	e, ok := c.Entries[key]
+	return e, ok
+}
+
+// Set caches the data for the key, persisting the cache if it has a file
+func (c *Cache) Set(key string, d *airvisual.Data, now time.Time) error {
+	c.Lock()
+	defer c.Unlock()
+	c.Entries[key] = &Entry{Data: d, Fetched: now}
+	if len(c.path) == 0 {
+		return nil
+	}
+	c.reload()
+	return c.save()
+}
+
+// reload takes the entries saved to the file by other processes, keeping the one fetched last
+// for each key, the entries in memory are kept if the file can't be read
+func (c *Cache) reload() {
+	data, err := ioutil.ReadFile(c.path)
+	if err != nil {
+		return
+	}
+	saved := &Cache{}
+	if json.Unmarshal(data, saved) != nil {
+		return
+	}
+	for key, e := range saved.Entries {
+		if e == nil {
+			continue
+		}
+		if current, ok := c.Entries[key]; !ok || e.Fetched.After(current.Fetched) {
+			c.Entries[key] = e
+		}
+	}
+}
+
+// save writes the cache to its file, replacing it atomically
+func (c *Cache) save() error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return exception.New(err)
+	}
+	return fileutil.WriteAtomic(c.path, data)
+}
@@ -0,0 +1,98 @@
+package cache
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mat285/aqi/pkg/airvisual"
+)
+
+var fetched = time.Date(2020, time.September, 7, 12, 20, 0, 0, time.UTC)
+
+func measured(t time.Time) *airvisual.Data {
+	d := &airvisual.Data{City: "San Francisco"}
+	d.Current.Pollution.Time = t
+	return d
+}
+
+func TestExpires(t *testing.T) {
+	cases := []struct {
+		name     string
+		data     *airvisual.Data
+		ttl      time.Duration
+		expected time.Time
+	}{
+		{"no data", nil, 10 * time.Minute, fetched.Add(10 * time.Minute)},
+		{"no reading time", measured(time.Time{}), 10 * time.Minute, fetched.Add(10 * time.Minute)},
+		{"ttl first", measured(fetched.Add(-10 * time.Minute)), 10 * time.Minute, fetched.Add(10 * time.Minute)},
+		{"next reading first", measured(fetched.Add(-50 * time.Minute)), 30 * time.Minute, fetched.Add(10 * time.Minute)},
+		{"next reading overdue", measured(fetched.Add(-2 * time.Hour)), 30 * time.Minute, fetched.Add(MinTTL)},
+		{"next reading just due", measured(fetched.Add(-58 * time.Minute)), 30 * time.Minute, fetched.Add(MinTTL)},
+		{"ttl below the minimum", measured(fetched.Add(-2 * time.Hour)), time.Minute, fetched.Add(-time.Hour)},
+	}
+	for _, c := range cases {
+		e := &Entry{Data: c.data, Fetched: fetched}
+		if expires := e.Expires(c.ttl); !expires.Equal(c.expected) {
+			t.Errorf("%s: expires at %s, expected %s", c.name, expires, c.expected)
+		}
+	}
+}
+
+func TestGet(t *testing.T) {
+	c := New(30 * time.Minute)
+	if err := c.Set("sf", measured(fetched.Add(-50*time.Minute)), fetched); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.Get("sf", fetched.Add(9*time.Minute)); !ok {
+		t.Error("expected the entry before the next reading is expected")
+	}
+	if _, ok := c.Get("sf", fetched.Add(10*time.Minute)); ok {
+		t.Error("expected the entry to expire once the next reading is expected")
+	}
+	if _, ok := c.Last("sf"); !ok {
+		t.Error("expected the last entry even once it has expired")
+	}
+	if _, ok := c.Get("oakland", fetched); ok {
+		t.Error("expected no entry for an uncached key")
+	}
+}
+
+func TestSetMergesSavedEntries(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "cache.json")
+	job, err := Load(path, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err := Load(path, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := job.Set("sf", measured(fetched), fetched); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.Set("sf", measured(fetched), fetched.Add(-time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.Set("oakland", measured(fetched), fetched); err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := Load(path, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e, ok := saved.Last("sf"); !ok || !e.Fetched.Equal(fetched) {
+		t.Errorf("expected the entry fetched last to be kept, got %+v", e)
+	}
+	if _, ok := saved.Last("oakland"); !ok {
+		t.Error("expected each process's entries to be kept")
+	}
+}
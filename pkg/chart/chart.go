@@ -0,0 +1,215 @@
+package chart
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	exception "github.com/blend/go-sdk/exception"
+	"github.com/mat285/aqi/pkg/epa"
+)
+
+const (
+	// DefaultWidth is the default width of a chart in pixels
+	DefaultWidth = 800
+	// DefaultHeight is the default height of a chart in pixels
+	DefaultHeight = 300
+	// Margin is the space around the plot in pixels
+	Margin = 12
+	// MinScale is the lowest aqi the top of the chart is scaled to
+	MinScale = 100
+	// BandOpacity is how strongly the category colors are drawn behind the line, out of 255
+	BandOpacity = 96
+)
+
+var (
+	// BackgroundColor is the color of the chart behind the bands
+	BackgroundColor = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	// GridColor is the color of the lines between categories and hours
+	GridColor = color.RGBA{R: 200, G: 200, B: 200, A: 255}
+	// LineColor is the color of the aqi line
+	LineColor = color.RGBA{R: 33, G: 33, B: 33, A: 255}
+)
+
+// Point is an aqi at a time
+type Point struct {
+	Time time.Time
+	AQI  int
+}
+
+// Chart is a line chart of aqi over time drawn over the epa category colors
+type Chart struct {
+	Width  int
+	Height int
+	Since  time.Time
+	Until  time.Time
+	Points []Point
+}
+
+// New returns a new chart of the points from since until until with the default size
+func New(since, until time.Time, points []Point) *Chart {
+	return &Chart{
+		Width:  DefaultWidth,
+		Height: DefaultHeight,
+		Since:  since,
+		Until:  until,
+		Points: points,
+	}
+}
+
+// Scale returns the aqi at the top of the chart, the top of the highest category reached
+func (c *Chart) Scale() int {
+	max := 0
+	for _, p := range c.Points {
+		if p.AQI > max {
+			max = p.AQI
+		}
+	}
+	scale := epa.CategoryForAQI(max).Max
+	if scale < MinScale {
+		return MinScale
+	}
+	return scale
+}
+
+// Draw draws the chart
+func (c *Chart) Draw() (*image.RGBA, error) {
+	if c.Width <= 2*Margin || c.Height <= 2*Margin {
+		return nil, exception.New("InvalidChartSize")
+	}
+	if !c.Until.After(c.Since) {
+		return nil, exception.New("InvalidChartPeriod")
+	}
+	img := image.NewRGBA(image.Rect(0, 0, c.Width, c.Height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: BackgroundColor}, image.Point{}, draw.Src)
+
+	scale := c.Scale()
+	for _, cat := range epa.Categories {
+		if cat.Min > scale {
+			break
+		}
+		top, bottom := c.y(cat.Max, scale), c.y(cat.Min-1, scale)
+		if cat.Min == 0 {
+			bottom = c.y(0, scale)
+		}
+		band := image.Rect(Margin, top, c.Width-Margin, bottom+1)
+		draw.Draw(img, band, &image.Uniform{C: Tint(cat.Color)}, image.Point{}, draw.Over)
+		c.hline(img, top, GridColor)
+	}
+	c.hline(img, c.y(0, scale), GridColor)
+	c.gridHours(img)
+
+	for i := 1; i < len(c.Points); i++ {
+		a, b := c.Points[i-1], c.Points[i]
+		line(img, c.x(a.Time), c.y(a.AQI, scale), c.x(b.Time), c.y(b.AQI, scale), LineColor)
+	}
+	for _, p := range c.Points {
+		dot(img, c.x(p.Time), c.y(p.AQI, scale), 2, LineColor)
+	}
+	return img, nil
+}
+
+// Render draws the chart and encodes it as a png
+func (c *Chart) Render(w io.Writer) error {
+	img, err := c.Draw()
+	if err != nil {
+		return err
+	}
+	return exception.New(png.Encode(w, img))
+}
+
+// x returns the horizontal pixel of the time
+func (c *Chart) x(t time.Time) int {
+	width := float64(c.Width - 2*Margin)
+	return Margin + int(width*float64(t.Sub(c.Since))/float64(c.Until.Sub(c.Since)))
+}
+
+// y returns the vertical pixel of the aqi
+func (c *Chart) y(aqi, scale int) int {
+	if aqi > scale {
+		aqi = scale
+	} else if aqi < 0 {
+		aqi = 0
+	}
+	height := float64(c.Height - 2*Margin)
+	return c.Height - Margin - int(height*float64(aqi)/float64(scale))
+}
+
+func (c *Chart) hline(img *image.RGBA, y int, col color.Color) {
+	for x := Margin; x < c.Width-Margin; x++ {
+		img.Set(x, y, col)
+	}
+}
+
+// gridHours draws a vertical line at each day, or each 6 hours for periods of two days or less
+func (c *Chart) gridHours(img *image.RGBA) {
+	step := 24 * time.Hour
+	if c.Until.Sub(c.Since) <= 48*time.Hour {
+		step = 6 * time.Hour
+	}
+	for t := c.Since.Truncate(step).Add(step); t.Before(c.Until); t = t.Add(step) {
+		x := c.x(t)
+		for y := Margin; y < c.Height-Margin; y += 2 {
+			img.Set(x, y, GridColor)
+		}
+	}
+}
+
+// Tint returns the translucent color of the hex color for a category band
+func Tint(hex string) color.RGBA {
+	v, err := strconv.ParseUint(strings.TrimPrefix(hex, "#"), 16, 32)
+	if err != nil {
+		return GridColor
+	}
+	scale := func(c uint64) uint8 { return uint8(c * BandOpacity / 255) }
+	return color.RGBA{R: scale(v >> 16 & 0xff), G: scale(v >> 8 & 0xff), B: scale(v & 0xff), A: BandOpacity}
+}
+
+// line draws a line two pixels thick between the points
+func line(img *image.RGBA, x0, y0, x1, y1 int, col color.Color) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := sign(x1-x0), sign(y1-y0)
+	err := dx + dy
+	for {
+		img.Set(x0, y0, col)
+		img.Set(x0, y0+1, col)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+// dot draws a filled square centered on the point
+func dot(img *image.RGBA, x, y, r int, col color.Color) {
+	draw.Draw(img, image.Rect(x-r, y-r, x+r+1, y+r+1), &image.Uniform{C: col}, image.Point{}, draw.Src)
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func sign(v int) int {
+	if v < 0 {
+		return -1
+	} else if v > 0 {
+		return 1
+	}
+	return 0
+}
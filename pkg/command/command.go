@@ -0,0 +1,212 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	exception "github.com/blend/go-sdk/exception"
+	"github.com/mat285/slack/slack"
+)
+
+const (
+	// ErrUnknownFlag is returned when a flag is not one of the command's flags
+	ErrUnknownFlag exception.Class = "UnknownFlag"
+	// ErrMissingFlagValue is returned when a flag that takes a value is not given one
+	ErrMissingFlagValue exception.Class = "MissingFlagValue"
+	// ErrMissingArgument is returned when a required argument is not given
+	ErrMissingArgument exception.Class = "MissingArgument"
+	// ErrTooManyArguments is returned when more arguments are given than the command takes
+	ErrTooManyArguments exception.Class = "TooManyArguments"
+
+	// FlagPrefix is the prefix of flags
+	FlagPrefix = "--"
+	// DefaultSlashCommand is the slash command shown in usage when the request does not say
+	DefaultSlashCommand = "/aqi"
+)
+
+// Handler handles a parsed command
+type Handler func(*Context) (*slack.Message, error)
+
+// Arg is a positional argument of a command
+type Arg struct {
+	Name        string
+	Description string
+	Optional    bool
+	// Variadic takes the rest of the arguments, it must be the last argument
+	Variadic bool
+}
+
+// Flag is a flag of a command, given as `--name value`, `--name=value`, or just `--name` if it is a bool
+type Flag struct {
+	Name        string
+	Description string
+	Bool        bool
+}
+
+// Command is a subcommand of the slash command
+type Command struct {
+	Name    string
+	Aliases []string
+	Summary string
+	Args    []Arg
+	Flags   []Flag
+	Handler Handler
+}
+
+// Flag returns the command's flag by name
+func (c *Command) Flag(name string) (*Flag, bool) {
+	for i := range c.Flags {
+		if c.Flags[i].Name == name {
+			return &c.Flags[i], true
+		}
+	}
+	return nil, false
+}
+
+// Usage returns the one line usage of the command, the default command is used without its name
+func (c *Command) Usage(slashCommand string, isDefault bool) string {
+	parts := []string{slashCommand}
+	if !isDefault {
+		parts = append(parts, c.Name)
+	}
+	for _, a := range c.Args {
+		name := a.Name
+		if a.Variadic {
+			name = name + "..."
+		}
+		if a.Optional {
+			name = fmt.Sprintf("[%s]", name)
+		} else {
+			name = fmt.Sprintf("<%s>", name)
+		}
+		parts = append(parts, name)
+	}
+	for _, f := range c.Flags {
+		if f.Bool {
+			parts = append(parts, fmt.Sprintf("[%s%s]", FlagPrefix, f.Name))
+		} else {
+			parts = append(parts, fmt.Sprintf("[%s%s <%s>]", FlagPrefix, f.Name, f.Name))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// Help returns the full help of the command with its arguments and flags
+func (c *Command) Help(slashCommand string, isDefault bool) string {
+	lines := []string{fmt.Sprintf("`%s`", c.Usage(slashCommand, isDefault)), c.Summary}
+	if len(c.Aliases) > 0 {
+		lines = append(lines, fmt.Sprintf("*Aliases:* %s", strings.Join(c.Aliases, ", ")))
+	}
+	if len(c.Args) > 0 {
+		lines = append(lines, "*Arguments:*")
+		for _, a := range c.Args {
+			lines = append(lines, fmt.Sprintf("• `%s` %s", a.Name, a.Description))
+		}
+	}
+	if len(c.Flags) > 0 {
+		lines = append(lines, "*Flags:*")
+		for _, f := range c.Flags {
+			lines = append(lines, fmt.Sprintf("• `%s%s` %s", FlagPrefix, f.Name, f.Description))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Context is a parsed invocation of a command
+type Context struct {
+	Request *slack.SlashCommandRequest
+	Command *Command
+	Args    []string
+	Flags   map[string]string
+}
+
+// Arg returns the positional argument at the index, or empty if it was not given
+func (c *Context) Arg(i int) string {
+	if i < 0 || i >= len(c.Args) {
+		return ""
+	}
+	return c.Args[i]
+}
+
+// Rest returns the positional arguments from the index joined back into text, quoting any with spaces
+func (c *Context) Rest(i int) string {
+	if i < 0 || i >= len(c.Args) {
+		return ""
+	}
+	return Join(c.Args[i:])
+}
+
+// Flag returns the value of the flag and if it was given
+func (c *Context) Flag(name string) (string, bool) {
+	v, ok := c.Flags[name]
+	return v, ok
+}
+
+// Bool returns if the bool flag was given
+func (c *Context) Bool(name string) bool {
+	_, ok := c.Flags[name]
+	return ok
+}
+
+// UserID returns the id of the user who invoked the command
+func (c *Context) UserID() string {
+	if c.Request == nil {
+		return ""
+	}
+	return c.Request.UserID
+}
+
+// Parse parses the tokens into the command's arguments and flags
+func (c *Command) Parse(tokens []string) (*Context, error) {
+	ctx := &Context{Command: c, Args: []string{}, Flags: map[string]string{}}
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		if !strings.HasPrefix(t, FlagPrefix) || len(t) == len(FlagPrefix) {
+			ctx.Args = append(ctx.Args, t)
+			continue
+		}
+		name, value := strings.ToLower(strings.TrimPrefix(t, FlagPrefix)), ""
+		hasValue := false
+		if eq := strings.Index(name, "="); eq >= 0 {
+			name, value, hasValue = name[:eq], t[len(FlagPrefix)+eq+1:], true
+		}
+		f, ok := c.Flag(name)
+		if !ok {
+			return nil, exception.New(ErrUnknownFlag).WithMessagef("%s%s", FlagPrefix, name)
+		}
+		if !f.Bool && !hasValue {
+			if i+1 >= len(tokens) {
+				return nil, exception.New(ErrMissingFlagValue).WithMessagef("%s%s", FlagPrefix, name)
+			}
+			i++
+			value = tokens[i]
+		}
+		ctx.Flags[name] = value
+	}
+	required, variadic := 0, false
+	for _, a := range c.Args {
+		if !a.Optional {
+			required++
+		}
+		variadic = variadic || a.Variadic
+	}
+	if len(ctx.Args) < required {
+		return nil, exception.New(ErrMissingArgument).WithMessage(c.Args[len(ctx.Args)].Name)
+	}
+	if !variadic && len(ctx.Args) > len(c.Args) {
+		return nil, exception.New(ErrTooManyArguments).WithMessage(strings.Join(ctx.Args[len(c.Args):], " "))
+	}
+	return ctx, nil
+}
+
+// Join joins the tokens back into text, quoting any with spaces
+func Join(tokens []string) string {
+	parts := make([]string, 0, len(tokens))
+	for _, t := range tokens {
+		if strings.Contains(t, " ") {
+			t = fmt.Sprintf("%q", t)
+		}
+		parts = append(parts, t)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,201 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	exception "github.com/blend/go-sdk/exception"
+	"github.com/mat285/slack/slack"
+)
+
+const (
+	// HelpCommand is the name of the help command every router has
+	HelpCommand = "help"
+	// PrivateFlag is the flag every command has for replying only to the user
+	PrivateFlag = "private"
+)
+
+// Router dispatches the slash command text to its subcommand, or the default command if the text does not
+// start with one
+type Router struct {
+	commands []*Command
+	byName   map[string]*Command
+	fallback *Command
+	ignored  map[string]bool
+	message  func(string) *slack.Message
+}
+
+// NewRouter returns a new router with the help command
+func NewRouter() *Router {
+	r := &Router{
+		byName:  map[string]*Command{},
+		ignored: map[string]bool{},
+		message: func(text string) *slack.Message { return &slack.Message{Text: text} },
+	}
+	r.Add(&Command{
+		Name:    HelpCommand,
+		Summary: "Lists everything the bot can do, or shows the help for a command.",
+		Args:    []Arg{{Name: "command", Description: "the command to show help for", Optional: true}},
+		Handler: r.help,
+	})
+	return r
+}
+
+// Add adds the command, every command gets the private flag
+func (r *Router) Add(c *Command) *Router {
+	if _, ok := c.Flag(PrivateFlag); !ok {
+		c.Flags = append(c.Flags, Flag{Name: PrivateFlag, Description: "replies only to you", Bool: true})
+	}
+	r.commands = append(r.commands, c)
+	for _, name := range append([]string{c.Name}, c.Aliases...) {
+		r.byName[strings.ToLower(name)] = c
+	}
+	return r
+}
+
+// WithDefault adds the command and runs it for text that does not start with a command
+func (r *Router) WithDefault(c *Command) *Router {
+	r.Add(c)
+	r.fallback = c
+	return r
+}
+
+// WithIgnored drops the words from the text wherever they appear before it is parsed
+func (r *Router) WithIgnored(words ...string) *Router {
+	for _, w := range words {
+		r.ignored[strings.ToLower(w)] = true
+	}
+	return r
+}
+
+// WithMessage sets how the router's own replies, help and usage, are made from their text
+func (r *Router) WithMessage(message func(string) *slack.Message) *Router {
+	r.message = message
+	return r
+}
+
+// Commands returns the commands in the order they were added
+func (r *Router) Commands() []*Command {
+	return r.commands
+}
+
+// Command returns the command with the name or alias
+func (r *Router) Command(name string) (*Command, bool) {
+	c, ok := r.byName[strings.ToLower(name)]
+	return c, ok
+}
+
+// Lookup returns the command the text invokes and the tokens after its name
+func (r *Router) Lookup(text string) (*Command, []string) {
+	tokens := r.tokenize(text)
+	if len(tokens) > 0 {
+		if c, ok := r.byName[strings.ToLower(tokens[0])]; ok {
+			return c, tokens[1:]
+		}
+	}
+	return r.fallback, tokens
+}
+
+// Named returns if the text starts with a command's name, rather than running the default command
+func (r *Router) Named(text string) bool {
+	tokens := r.tokenize(text)
+	if len(tokens) == 0 {
+		return false
+	}
+	_, ok := r.byName[strings.ToLower(tokens[0])]
+	return ok
+}
+
+// tokenize splits the text into tokens without the ignored words
+func (r *Router) tokenize(text string) []string {
+	tokens := []string{}
+	for _, t := range Tokenize(text) {
+		if !r.ignored[strings.ToLower(t)] {
+			tokens = append(tokens, t)
+		}
+	}
+	return tokens
+}
+
+// Handle parses the request's text and runs its command,
+// replying with the command's usage if the text does not parse
+func (r *Router) Handle(sr *slack.SlashCommandRequest) (*slack.Message, error) {
+	c, tokens := r.Lookup(sr.Text)
+	if c == nil {
+		return r.usageMessage(sr, nil, exception.New("UnknownCommand")), nil
+	}
+	ctx, err := c.Parse(tokens)
+	if err != nil {
+		return r.usageMessage(sr, c, err), nil
+	}
+	ctx.Request = sr
+	m, err := c.Handler(ctx)
+	if err != nil || m == nil {
+		return m, err
+	}
+	if ctx.Bool(PrivateFlag) {
+		m.ResponseType = slack.ResponseTypeEphemeral
+	}
+	return m, nil
+}
+
+// Help returns the list of every command with its usage and summary
+func (r *Router) Help(slashCommand string) string {
+	lines := []string{"Here's everything I can do:"}
+	for _, c := range r.commands {
+		if c.Name != HelpCommand {
+			lines = append(lines, fmt.Sprintf("• `%s` %s", c.Usage(slashCommand, c == r.fallback), c.Summary))
+		}
+	}
+	lines = append(lines, fmt.Sprintf("Use `%s %s <command>` for more about a command.", slashCommand, HelpCommand))
+	return strings.Join(lines, "\n")
+}
+
+func (r *Router) help(ctx *Context) (*slack.Message, error) {
+	slashCommand := SlashCommand(ctx.Request)
+	text := r.Help(slashCommand)
+	if name := ctx.Arg(0); len(name) > 0 {
+		if c, ok := r.byName[strings.ToLower(name)]; ok {
+			text = c.Help(slashCommand, c == r.fallback)
+		} else {
+			text = fmt.Sprintf("There's no `%s` command.\n%s", name, text)
+		}
+	}
+	return r.ephemeral(text), nil
+}
+
+func (r *Router) usageMessage(sr *slack.SlashCommandRequest, c *Command, err error) *slack.Message {
+	slashCommand := SlashCommand(sr)
+	if c == nil {
+		return r.ephemeral(r.Help(slashCommand))
+	}
+	reason, ok := errorTexts[exception.ErrClass(err)]
+	if !ok {
+		reason = exception.ErrClass(err)
+	}
+	if message := exception.ErrMessage(err); len(message) > 0 {
+		reason = fmt.Sprintf("%s `%s`", reason, message)
+	}
+	return r.ephemeral(fmt.Sprintf("%s\n%s", reason, c.Help(slashCommand, c == r.fallback)))
+}
+
+// SlashCommand returns the slash command of the request
+func SlashCommand(sr *slack.SlashCommandRequest) string {
+	if sr == nil || len(sr.Command) == 0 {
+		return DefaultSlashCommand
+	}
+	return sr.Command
+}
+
+var errorTexts = map[string]string{
+	string(ErrUnknownFlag):      "Unknown flag",
+	string(ErrMissingFlagValue): "Missing a value for the flag",
+	string(ErrMissingArgument):  "Missing the argument",
+	string(ErrTooManyArguments): "Too many arguments",
+}
+
+func (r *Router) ephemeral(text string) *slack.Message {
+	m := r.message(text)
+	m.ResponseType = slack.ResponseTypeEphemeral
+	return m
+}
@@ -0,0 +1,117 @@
+package command
+
+import (
+	"testing"
+
+	"github.com/mat285/slack/slack"
+)
+
+func newTestRouter() *Router {
+	echo := func(ctx *Context) (*slack.Message, error) {
+		return &slack.Message{Text: ctx.Command.Name + ":" + ctx.Rest(0), ResponseType: slack.ResponseTypeInChannel}, nil
+	}
+	return NewRouter().
+		WithIgnored("please").
+		WithMessage(func(text string) *slack.Message { return &slack.Message{Text: text, Username: "test"} }).
+		WithDefault(&Command{Name: "aqi", Args: []Arg{{Name: "location", Optional: true, Variadic: true}}, Handler: echo}).
+		Add(&Command{Name: "forecast", Aliases: []string{"fc"}, Args: []Arg{{Name: "location", Optional: true, Variadic: true}}, Handler: echo}).
+		Add(&Command{Name: "alias", Args: []Arg{{Name: "name"}, {Name: "location"}}, Handler: echo})
+}
+
+func TestRouterHandle(t *testing.T) {
+	cases := []struct {
+		text     string
+		expected string
+	}{
+		{"", "aqi:"},
+		{"sf", "aqi:sf"},
+		{"sf please", "aqi:sf"},
+		{"please sf", "aqi:sf"},
+		{"portland PLEASE oregon", "aqi:portland oregon"},
+		{"please", "aqi:"},
+		{"forecast sf", "forecast:sf"},
+		{"please fc sf please", "forecast:sf"},
+		{"FC sf", "forecast:sf"},
+		{`city "San Francisco" California USA`, `aqi:city "San Francisco" California USA`},
+		{"pleased", "aqi:pleased"},
+	}
+	r := newTestRouter()
+	for _, c := range cases {
+		m, err := r.Handle(&slack.SlashCommandRequest{Text: c.text})
+		if err != nil {
+			t.Errorf("%q: %v", c.text, err)
+			continue
+		}
+		if m == nil || m.Text != c.expected {
+			t.Errorf("%q: replied %v, expected %q", c.text, m, c.expected)
+		}
+	}
+}
+
+func TestRouterHandlePrivate(t *testing.T) {
+	m, err := newTestRouter().Handle(&slack.SlashCommandRequest{Text: "sf --private"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Text != "aqi:sf" || m.ResponseType != slack.ResponseTypeEphemeral {
+		t.Errorf("expected a private reply for sf, got %q %q", m.Text, m.ResponseType)
+	}
+}
+
+func TestRouterHandleUsage(t *testing.T) {
+	for _, text := range []string{"alias please", "alias a b c", "sf --unknown", "help"} {
+		m, err := newTestRouter().Handle(&slack.SlashCommandRequest{Text: text})
+		if err != nil {
+			t.Errorf("%q: %v", text, err)
+			continue
+		}
+		if m.Username != "test" || m.ResponseType != slack.ResponseTypeEphemeral {
+			t.Errorf("%q: expected a private reply made by the router's message, got %+v", text, m)
+		}
+	}
+}
+
+func TestRouterNamed(t *testing.T) {
+	r := newTestRouter()
+	for text, expected := range map[string]bool{
+		"":                false,
+		"sf":              false,
+		"please sf":       false,
+		"forecast sf":     true,
+		"please fc":       true,
+		"sf forecast":     false,
+		"help":            true,
+		"alias home sf":   true,
+		"\"forecast\" sf": true,
+	} {
+		if named := r.Named(text); named != expected {
+			t.Errorf("%q: named %v, expected %v", text, named, expected)
+		}
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	cases := []struct {
+		text     string
+		expected []string
+	}{
+		{"", []string{}},
+		{"  sf  ", []string{"sf"}},
+		{"portland oregon", []string{"portland", "oregon"}},
+		{`city "San Francisco" "California" "USA"`, []string{"city", "San Francisco", "California", "USA"}},
+		{"city “New York” “New York” USA", []string{"city", "New York", "New York", "USA"}},
+	}
+	for _, c := range cases {
+		tokens := Tokenize(c.text)
+		if len(tokens) != len(c.expected) {
+			t.Errorf("%q: tokenized %q, expected %q", c.text, tokens, c.expected)
+			continue
+		}
+		for i := range tokens {
+			if tokens[i] != c.expected[i] {
+				t.Errorf("%q: tokenized %q, expected %q", c.text, tokens, c.expected)
+				break
+			}
+		}
+	}
+}
@@ -1,15 +1,17 @@
-package util
+package command
+
+import "strings"
 
 const (
 	slackOpenQuote  = '“'
 	slackCloseQuote = '”'
 )
 
-// SplitOnSpacePreserveQuotes splits the string on spaces preserving whitespace in quotes
-func SplitOnSpacePreserveQuotes(str string) []string {
+// Tokenize splits the text into tokens on spaces, keeping quoted text together
+func Tokenize(text string) []string {
 	ret := []string{}
 	state := 0
-	runes := []rune(str)
+	runes := []rune(strings.TrimSpace(text))
 	curr := []rune{}
 	for i := 0; i < len(runes); i++ {
 		r := runes[i]
@@ -1,48 +1,499 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/blend/go-sdk/env"
 	exception "github.com/blend/go-sdk/exception"
+	"github.com/blend/go-sdk/yaml"
+)
+
+const (
+	// EnvVarConfigFile is the env var for the path of the yaml or json config file
+	EnvVarConfigFile = "AQI_CONFIG_FILE"
+	// EnvVarAlertThresholds is the env var for the csv alert thresholds
+	EnvVarAlertThresholds = "AQI_ALERT_THRESHOLDS"
+
+	// DefaultAlertThreshold is the alert threshold when none are configured
+	DefaultAlertThreshold = 100
+	// DefaultAlertHysteresis is the alert hysteresis when none is configured
+	DefaultAlertHysteresis = 50
+	// DefaultAlertStateFile is the alert state file when none is configured
+	DefaultAlertStateFile = "aqi-alert-state.json"
+
+	// DefaultHistoryFile is the database readings are recorded to when none is configured
+	DefaultHistoryFile = "aqi-history.db"
+	// LegacyHistoryFile is the json lines file readings were recorded to by default before they were kept in a database
+	LegacyHistoryFile = "aqi-history.jsonl"
+	// DefaultHistoryRetention is how long readings are kept when no retention is configured
+	DefaultHistoryRetention = 90 * 24 * time.Hour
+
+	// DefaultAliasesFile is where aliases added at runtime are kept when none is configured
+	DefaultAliasesFile = "aqi-aliases.json"
+
+	// DefaultPreferencesFile is where user preferences are kept when none is configured
+	DefaultPreferencesFile = "aqi-preferences.json"
+
+	// DefaultAirVisualQuotaFile is the airvisual quota file when none is configured
+	DefaultAirVisualQuotaFile = "airvisual-quota.json"
+
+	// DefaultCacheTTL is how long readings are cached when no ttl is configured
+	DefaultCacheTTL = 30 * time.Minute
+
+	// DefaultSchedule is the cron schedule of the default report, hourly
+	DefaultSchedule = "0 * * * *"
+	// DefaultReportLocation is the location of the default report
+	DefaultReportLocation = "sf"
+	// DefaultSlackChannel is the channel posted to when none is configured
+	DefaultSlackChannel = "slack-bot-test"
+)
+
+// DefaultAsyncCommands are the slash subcommands answered asynchronously when none are configured
+var DefaultAsyncCommands = []string{"aqi", "cigarettes", "forecast", "station", "locations", "history"}
+
+// AsyncCommandsNone is the async commands setting for answering every command synchronously
+const AsyncCommandsNone = "none"
+
+const (
+	// ProviderAirVisual is the airvisual provider
+	ProviderAirVisual = "airvisual"
+	// ProviderAirNow is the airnow provider
+	ProviderAirNow = "airnow"
+	// ProviderOpenAQ is the openaq provider
+	ProviderOpenAQ = "openaq"
+	// ProviderWAQI is the world air quality index provider
+	ProviderWAQI = "waqi"
 )
 
 // Config configures the project
 type Config struct {
 	AirVisualAPIKey string `yaml:"airvisualAPIKey" env:"AIRVISUAL_API_KEY"`
-	SlackWebhook    string `yaml:"slackWebhook" env:"SLACK_WEBHOOK"`
-	SlackChannel    string `yaml:"slackChannel" env:"SLACK_CHANNEL"`
+	AirNowAPIKey    string `yaml:"airnowAPIKey" env:"AIRNOW_API_KEY"`
+	OpenAQAPIKey    string `yaml:"openaqAPIKey" env:"OPENAQ_API_KEY"`
+	WAQIToken       string `yaml:"waqiToken" env:"WAQI_TOKEN"`
+
+	// AirVisualRateLimit is the most airvisual calls to make a minute, unlimited if zero
+	AirVisualRateLimit int `yaml:"airvisualRateLimit" env:"AIRVISUAL_RATE_LIMIT"`
+	// AirVisualMonthlyQuota is the most airvisual calls to make a calendar month, unlimited if zero
+	AirVisualMonthlyQuota int `yaml:"airvisualMonthlyQuota" env:"AIRVISUAL_MONTHLY_QUOTA"`
+	// AirVisualQuotaFile is where airvisual usage is counted between runs
+	AirVisualQuotaFile string `yaml:"airvisualQuotaFile" env:"AIRVISUAL_QUOTA_FILE"`
+
+	Providers    []string `yaml:"providers" env:"AQI_PROVIDERS,csv"`
+	SlackWebhook string   `yaml:"slackWebhook" env:"SLACK_WEBHOOK"`
+	SlackChannel string   `yaml:"slackChannel" env:"SLACK_CHANNEL"`
+
+	// Index is the air quality index to report in, `us` by default or `local` for each location's own index
+	Index string `yaml:"index" env:"AQI_INDEX"`
+	// LocationIndexes are the indexes to report in keyed by city or country
+	LocationIndexes map[string]string `yaml:"locationIndexes"`
+	// UserIndexes are the indexes to report in keyed by slack user id
+	UserIndexes map[string]string `yaml:"userIndexes"`
+
+	// AlertMode makes the job only post when the aqi crosses an alert threshold
+	AlertMode bool `yaml:"alertMode" env:"AQI_ALERT_MODE"`
+	// AlertThresholds are the aqi thresholds to alert on, read from AQI_ALERT_THRESHOLDS as csv
+	AlertThresholds []int `yaml:"alertThresholds"`
+	// AlertHysteresis is how far below a threshold the aqi must drop to clear it, zero to clear as soon as it drops below,
+	// the default if unset
+	AlertHysteresis *int `yaml:"alertHysteresis" env:"AQI_ALERT_HYSTERESIS"`
+	// AlertStateFile is where the last alerted state is kept between runs
+	AlertStateFile string `yaml:"alertStateFile" env:"AQI_ALERT_STATE_FILE"`
+
+	// HistoryFile is the database every fetched reading is recorded to
+	HistoryFile string `yaml:"historyFile" env:"AQI_HISTORY_FILE"`
+	// HistoryRetention is how long readings are kept, negative to keep them forever
+	HistoryRetention time.Duration `yaml:"historyRetention" env:"AQI_HISTORY_RETENTION"`
+
+	// Aliases are short names for locations, each in the same format as the slash command
+	Aliases map[string]string `yaml:"aliases"`
+	// AliasesFile is where aliases added with the slash command are kept
+	AliasesFile string `yaml:"aliasesFile" env:"AQI_ALIASES_FILE"`
+	// Admins are the slack user ids allowed to add and remove aliases
+	Admins []string `yaml:"admins" env:"AQI_ADMINS,csv"`
+
+	// PreferencesFile is where the preferences users set with the slash command are kept
+	PreferencesFile string `yaml:"preferencesFile" env:"AQI_PREFERENCES_FILE"`
+
+	// AsyncCommands are the slash subcommands acknowledged right away and answered through the response url,
+	// all the commands that fetch air data by default or none if set to `none`
+	AsyncCommands []string `yaml:"asyncCommands" env:"AQI_ASYNC_COMMANDS,csv"`
+
+	// CacheTTL is how long readings are cached for, negative to disable the cache
+	CacheTTL time.Duration `yaml:"cacheTTL" env:"AQI_CACHE_TTL"`
+	// CacheFile is where the cache is persisted between restarts, the cache is only kept in memory if unset
+	CacheFile string `yaml:"cacheFile" env:"AQI_CACHE_FILE"`
+
+	// Daemon makes the job run continuously, posting reports on their schedules
+	Daemon bool `yaml:"daemon" env:"AQI_DAEMON"`
+	// Schedule is the cron schedule of reports without their own
+	Schedule string `yaml:"schedule" env:"AQI_SCHEDULE"`
+	// ScheduleTimezone is the timezone schedules are evaluated in, utc by default
+	ScheduleTimezone string `yaml:"scheduleTimezone" env:"AQI_SCHEDULE_TIMEZONE"`
+	// Reports are the reports the job posts, each to its own channel on its own schedule
+	Reports []Report `yaml:"reports"`
+}
+
+// Report is a report of the air quality of locations to a slack channel
+type Report struct {
+	// Name identifies the report in logs
+	Name string `yaml:"name"`
+	// Schedule is the cron schedule the daemon posts the report on
+	Schedule string `yaml:"schedule"`
+	// Location is a single location to report, in the same format as the slash command
+	Location string `yaml:"location"`
+	// Locations are the locations to report, in the same format as the slash command
+	Locations []string `yaml:"locations"`
+	// Webhook is the slack webhook to post to, the config's webhook by default
+	Webhook string `yaml:"webhook"`
+	// Channel is the slack channel to post to, the config's channel by default
+	Channel string `yaml:"channel"`
+	// Template is an optional go text template for the message text
+	Template string `yaml:"template"`
 }
 
-// NewFromFile returns a new config from a file
+// GetLocations returns all the locations of the report
+func (r Report) GetLocations() []string {
+	ret := []string{}
+	for _, l := range append([]string{r.Location}, r.Locations...) {
+		if len(strings.TrimSpace(l)) > 0 {
+			ret = append(ret, l)
+		}
+	}
+	return ret
+}
+
+// GetName returns the name of the report
+func (r Report) GetName() string {
+	if len(r.Name) > 0 {
+		return r.Name
+	}
+	return fmt.Sprintf("%s to %s", strings.Join(r.GetLocations(), ", "), r.Channel)
+}
+
+// Load returns a new validated config from the file named by AQI_CONFIG_FILE if it is set,
+// with any values set in the environment taking precedence over the file
+func Load() (*Config, error) {
+	c := &Config{}
+	if file := env.Env().String(EnvVarConfigFile); len(file) > 0 {
+		fc, err := NewFromFile(file)
+		if err != nil {
+			return nil, err
+		}
+		c = fc
+	}
+	err := c.ReadEnv()
+	if err != nil {
+		return c, err
+	}
+	return c, c.Validate()
+}
+
+// NewFromFile returns a new config from a yaml or json file, detected by the extension or else the contents
 func NewFromFile(file string) (*Config, error) {
 	data, err := ioutil.ReadFile(file)
 	if err != nil {
 		return nil, exception.New(err)
 	}
 	c := &Config{}
-	return c, exception.New(json.Unmarshal(data, c))
+	if isJSON(file, data) {
+		return c, exception.New(json.Unmarshal(data, c))
+	}
+	return c, exception.New(yaml.Unmarshal(data, c))
+}
+
+func isJSON(file string, data []byte) bool {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".json":
+		return true
+	case ".yaml", ".yml":
+		return false
+	}
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("{"))
 }
 
 // NewFromEnv returns a new config from the environment
 func NewFromEnv() (*Config, error) {
 	c := &Config{}
-	return c, env.Env().ReadInto(c)
+	return c, c.ReadEnv()
+}
+
+// ReadEnv overrides the config with any values set in the environment
+func (c *Config) ReadEnv() error {
+	err := env.Env().ReadInto(c)
+	if err != nil {
+		return err
+	}
+	if env.Env().Has(EnvVarAlertThresholds) {
+		thresholds, err := parseInts(env.Env().CSV(EnvVarAlertThresholds))
+		if err != nil {
+			return err
+		}
+		c.AlertThresholds = thresholds
+	}
+	return nil
+}
+
+func parseInts(values []string) ([]int, error) {
+	ret := []int{}
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if len(v) == 0 {
+			continue
+		}
+		i, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, exception.New(err)
+		}
+		ret = append(ret, i)
+	}
+	return ret, nil
 }
 
-// Validate validates the config
+// Validate validates the config shared by the job and server
 func (c *Config) Validate() error {
 	if c == nil {
 		return exception.New("NilConfig")
-	} else if len(c.AirVisualAPIKey) == 0 {
-		return exception.New("MissingAPIKey")
-	} else if len(c.SlackWebhook) == 0 {
-		return exception.New("MissingSlackWebhook")
+	}
+	err := c.ValidateProviders()
+	if err != nil {
+		return err
+	}
+	for _, t := range c.AlertThresholds {
+		if t <= 0 {
+			return exception.New("InvalidAlertThreshold").WithMessagef("%d", t)
+		}
+	}
+	for name, location := range c.Aliases {
+		if len(strings.TrimSpace(name)) == 0 || len(strings.TrimSpace(location)) == 0 {
+			return exception.New("InvalidAlias").WithMessagef("%q: %q", name, location)
+		}
+	}
+	_, err = c.GetScheduleLocation()
+	return err
+}
+
+// ValidateReports validates every report has locations and somewhere to post, which only the job needs
+func (c *Config) ValidateReports() error {
+	for _, r := range c.GetReports() {
+		if len(r.GetLocations()) == 0 {
+			return exception.New("MissingReportLocations").WithMessage(r.GetName())
+		}
+		if len(r.Webhook) == 0 {
+			return exception.New("MissingSlackWebhook").WithMessage(r.GetName())
+		}
 	}
 	return nil
 }
 
+// ValidateProviders validates the providers are known and have credentials
+func (c *Config) ValidateProviders() error {
+	for _, p := range c.GetProviders() {
+		switch p {
+		case ProviderAirVisual:
+			if len(c.AirVisualAPIKey) == 0 {
+				return exception.New("MissingAPIKey")
+			}
+		case ProviderAirNow:
+			if len(c.AirNowAPIKey) == 0 {
+				return exception.New("MissingAirNowAPIKey")
+			}
+		case ProviderWAQI:
+			if len(c.WAQIToken) == 0 {
+				return exception.New("MissingWAQIToken")
+			}
+		case ProviderOpenAQ:
+			if len(c.OpenAQAPIKey) == 0 {
+				return exception.New("MissingOpenAQAPIKey")
+			}
+		default:
+			return exception.New("UnknownProvider").WithMessage(p)
+		}
+	}
+	return nil
+}
+
+// GetProviders returns the providers to fetch air data from in order of preference
+func (c *Config) GetProviders() []string {
+	ret := []string{}
+	for _, p := range c.Providers {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if len(p) > 0 {
+			ret = append(ret, p)
+		}
+	}
+	if len(ret) == 0 {
+		return []string{ProviderAirVisual}
+	}
+	return ret
+}
+
+// GetIndex returns the name of the index to report in for the user and location,
+// users take precedence over cities which take precedence over countries
+func (c *Config) GetIndex(user, city, country string) string {
+	if i, ok := c.UserIndexes[user]; ok && len(user) > 0 {
+		return i
+	}
+	for _, key := range []string{city, country} {
+		for loc, i := range c.LocationIndexes {
+			if len(key) > 0 && strings.EqualFold(loc, key) {
+				return i
+			}
+		}
+	}
+	return c.Index
+}
+
+// GetAlertThresholds returns the alert thresholds
+func (c *Config) GetAlertThresholds() []int {
+	if len(c.AlertThresholds) == 0 {
+		return []int{DefaultAlertThreshold}
+	}
+	return c.AlertThresholds
+}
+
+// GetAlertHysteresis returns the alert hysteresis, zero if it is disabled
+func (c *Config) GetAlertHysteresis() int {
+	if c.AlertHysteresis == nil {
+		return DefaultAlertHysteresis
+	} else if *c.AlertHysteresis < 0 {
+		return 0
+	}
+	return *c.AlertHysteresis
+}
+
+// GetAlertStateFile returns the alert state file
+func (c *Config) GetAlertStateFile() string {
+	if len(c.AlertStateFile) == 0 {
+		return DefaultAlertStateFile
+	}
+	return c.AlertStateFile
+}
+
+// GetHistoryFile returns the history file
+func (c *Config) GetHistoryFile() string {
+	if len(c.HistoryFile) == 0 {
+		return DefaultHistoryFile
+	}
+	return c.HistoryFile
+}
+
+// GetHistoryRetention returns how long readings are kept, zero if they are kept forever
+func (c *Config) GetHistoryRetention() time.Duration {
+	if c.HistoryRetention < 0 {
+		return 0
+	} else if c.HistoryRetention == 0 {
+		return DefaultHistoryRetention
+	}
+	return c.HistoryRetention
+}
+
+// GetAirVisualQuotaFile returns the airvisual quota file
+func (c *Config) GetAirVisualQuotaFile() string {
+	if len(c.AirVisualQuotaFile) == 0 {
+		return DefaultAirVisualQuotaFile
+	}
+	return c.AirVisualQuotaFile
+}
+
+// GetAliasesFile returns the file aliases added at runtime are kept in
+func (c *Config) GetAliasesFile() string {
+	if len(c.AliasesFile) == 0 {
+		return DefaultAliasesFile
+	}
+	return c.AliasesFile
+}
+
+// GetPreferencesFile returns the file user preferences are kept in
+func (c *Config) GetPreferencesFile() string {
+	if len(c.PreferencesFile) == 0 {
+		return DefaultPreferencesFile
+	}
+	return c.PreferencesFile
+}
+
+// IsAdmin returns if the slack user is allowed to change aliases
+func (c *Config) IsAdmin(user string) bool {
+	for _, a := range c.Admins {
+		if len(user) > 0 && strings.TrimSpace(a) == user {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAsyncCommand returns if the slash subcommand is acknowledged right away and answered through the response url
+func (c *Config) IsAsyncCommand(command string) bool {
+	commands := c.AsyncCommands
+	if len(commands) == 0 {
+		commands = DefaultAsyncCommands
+	}
+	for _, a := range commands {
+		a = strings.ToLower(strings.TrimSpace(a))
+		if a == AsyncCommandsNone {
+			return false
+		}
+		if a == strings.ToLower(command) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetCacheTTL returns how long readings are cached for, zero if the cache is disabled
+func (c *Config) GetCacheTTL() time.Duration {
+	if c.CacheTTL < 0 {
+		return 0
+	} else if c.CacheTTL == 0 {
+		return DefaultCacheTTL
+	}
+	return c.CacheTTL
+}
+
+// GetReports returns the reports with the config's schedule, webhook and channel filled in where they are unset,
+// defaulting to one report of the default location
+func (c *Config) GetReports() []Report {
+	reports := c.Reports
+	if len(reports) == 0 {
+		reports = []Report{{Location: DefaultReportLocation}}
+	}
+	ret := make([]Report, len(reports))
+	for i, r := range reports {
+		if len(r.Schedule) == 0 {
+			r.Schedule = c.GetSchedule()
+		}
+		if len(r.Webhook) == 0 {
+			r.Webhook = c.SlackWebhook
+		}
+		if len(r.Channel) == 0 {
+			r.Channel = c.GetSlackChannel(DefaultSlackChannel)
+		}
+		ret[i] = r
+	}
+	return ret
+}
+
+// GetSchedule returns the cron schedule of reports without their own
+func (c *Config) GetSchedule() string {
+	if len(c.Schedule) == 0 {
+		return DefaultSchedule
+	}
+	return c.Schedule
+}
+
+// GetScheduleLocation returns the timezone schedules are evaluated in
+func (c *Config) GetScheduleLocation() (*time.Location, error) {
+	loc, err := time.LoadLocation(c.ScheduleTimezone)
+	return loc, exception.New(err)
+}
+
 // GetSlackChannel returns the slack channel
 func (c *Config) GetSlackChannel(defaults ...string) string {
 	if len(c.SlackChannel) > 0 {
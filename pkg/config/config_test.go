@@ -0,0 +1,40 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGetAlertHysteresis(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cases := []struct {
+		file     string
+		data     string
+		expected int
+	}{
+		{"unset.yml", "alertMode: true\n", DefaultAlertHysteresis},
+		{"zero.yml", "alertHysteresis: 0\n", 0},
+		{"set.yml", "alertHysteresis: 10\n", 10},
+		{"negative.yml", "alertHysteresis: -5\n", 0},
+		{"zero.json", `{"alertHysteresis": 0}`, 0},
+	}
+	for _, c := range cases {
+		path := filepath.Join(dir, c.file)
+		if err := ioutil.WriteFile(path, []byte(c.data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		config, err := NewFromFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hysteresis := config.GetAlertHysteresis(); hysteresis != c.expected {
+			t.Errorf("%s: hysteresis %d, expected %d", c.file, hysteresis, c.expected)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package epa
+
+import "math"
+
+// Category is an epa aqi category with its official color and health guidance
+type Category struct {
+	Name string
+	Min  int
+	Max  int
+	// Color is the official hex color of the category
+	Color string
+	// HealthImplications is the health advisory for the general public
+	HealthImplications string
+	// SensitiveGroups is the cautionary statement for sensitive groups
+	SensitiveGroups string
+}
+
+var (
+	// Good is the good category
+	Good = Category{
+		Name:               "Good",
+		Min:                0,
+		Max:                50,
+		Color:              "#00E400",
+		HealthImplications: "Air quality is satisfactory, and air pollution poses little or no risk.",
+		SensitiveGroups:    "None.",
+	}
+	// Moderate is the moderate category
+	Moderate = Category{
+		Name:               "Moderate",
+		Min:                51,
+		Max:                100,
+		Color:              "#FFFF00",
+		HealthImplications: "Air quality is acceptable. However, there may be a risk for some people, particularly those who are unusually sensitive to air pollution.",
+		SensitiveGroups:    "Unusually sensitive people should consider reducing prolonged or heavy exertion.",
+	}
+	// UnhealthyForSensitiveGroups is the unhealthy for sensitive groups category
+	UnhealthyForSensitiveGroups = Category{
+		Name:               "Unhealthy for Sensitive Groups",
+		Min:                101,
+		Max:                150,
+		Color:              "#FF7E00",
+		HealthImplications: "Members of sensitive groups may experience health effects. The general public is less likely to be affected.",
+		SensitiveGroups:    "People with heart or lung disease, older adults, children, and people who are active outdoors should reduce prolonged or heavy exertion.",
+	}
+	// Unhealthy is the unhealthy category
+	Unhealthy = Category{
+		Name:               "Unhealthy",
+		Min:                151,
+		Max:                200,
+		Color:              "#FF0000",
+		HealthImplications: "Some members of the general public may experience health effects; members of sensitive groups may experience more serious health effects.",
+		SensitiveGroups:    "Sensitive groups should avoid prolonged or heavy exertion; everyone else should reduce prolonged or heavy exertion.",
+	}
+	// VeryUnhealthy is the very unhealthy category
+	VeryUnhealthy = Category{
+		Name:               "Very Unhealthy",
+		Min:                201,
+		Max:                300,
+		Color:              "#8F3F97",
+		HealthImplications: "Health alert: The risk of health effects is increased for everyone.",
+		SensitiveGroups:    "Sensitive groups should avoid all physical activity outdoors; everyone else should avoid prolonged or heavy exertion.",
+	}
+	// Hazardous is the hazardous category
+	Hazardous = Category{
+		Name:               "Hazardous",
+		Min:                301,
+		Max:                math.MaxInt32,
+		Color:              "#7E0023",
+		HealthImplications: "Health warning of emergency conditions: everyone is more likely to be affected.",
+		SensitiveGroups:    "Everyone should avoid all physical activity outdoors; sensitive groups should remain indoors and keep activity levels low.",
+	}
+
+	// Categories are the categories in order of increasing aqi
+	Categories = []Category{
+		Good,
+		Moderate,
+		UnhealthyForSensitiveGroups,
+		Unhealthy,
+		VeryUnhealthy,
+		Hazardous,
+	}
+)
+
+// CategoryForAQI returns the category the aqi falls in
+func CategoryForAQI(aqi int) Category {
+	for _, c := range Categories {
+		if aqi <= c.Max {
+			return c
+		}
+	}
+	return Hazardous
+}
+
+// Severity returns the rank of the category from 0 for good through 5 for hazardous
+func (c Category) Severity() int {
+	for i, cat := range Categories {
+		if cat.Name == c.Name {
+			return i
+		}
+	}
+	return len(Categories) - 1
+}
@@ -0,0 +1,232 @@
+package epa
+
+import (
+	"math"
+
+	"github.com/mat285/aqi/pkg/airvisual"
+)
+
+const (
+	// MaxAQI is the top of the aqi scale, concentrations beyond the tables are capped to it
+	MaxAQI = 500
+)
+
+// Breakpoint maps a concentration range to an aqi range
+type Breakpoint struct {
+	Low       float64
+	High      float64
+	IndexLow  int
+	IndexHigh int
+}
+
+// Table is a breakpoint table for a pollutant and averaging period
+type Table struct {
+	// Precision is the number of decimals concentrations are truncated to before lookup
+	Precision   int
+	Breakpoints []Breakpoint
+}
+
+var (
+	// PM25 is the 24 hour pm2.5 table in ug/m3
+	PM25 = Table{Precision: 1, Breakpoints: []Breakpoint{
+		{0.0, 9.0, 0, 50},
+		{9.1, 35.4, 51, 100},
+		{35.5, 55.4, 101, 150},
+		{55.5, 125.4, 151, 200},
+		{125.5, 225.4, 201, 300},
+		{225.5, 325.4, 301, 500},
+	}}
+	// PM10 is the 24 hour pm10 table in ug/m3
+	PM10 = Table{Precision: 0, Breakpoints: []Breakpoint{
+		{0, 54, 0, 50},
+		{55, 154, 51, 100},
+		{155, 254, 101, 150},
+		{255, 354, 151, 200},
+		{355, 424, 201, 300},
+		{425, 604, 301, 500},
+	}}
+	// O3EightHour is the 8 hour ozone table in ppm, it is not defined above 300
+	O3EightHour = Table{Precision: 3, Breakpoints: []Breakpoint{
+		{0.000, 0.054, 0, 50},
+		{0.055, 0.070, 51, 100},
+		{0.071, 0.085, 101, 150},
+		{0.086, 0.105, 151, 200},
+		{0.106, 0.200, 201, 300},
+	}}
+	// O3OneHour is the 1 hour ozone table in ppm, it is not defined below 101
+	O3OneHour = Table{Precision: 3, Breakpoints: []Breakpoint{
+		{0.125, 0.164, 101, 150},
+		{0.165, 0.204, 151, 200},
+		{0.205, 0.404, 201, 300},
+		{0.405, 0.604, 301, 500},
+	}}
+	// CO is the 8 hour carbon monoxide table in ppm
+	CO = Table{Precision: 1, Breakpoints: []Breakpoint{
+		{0.0, 4.4, 0, 50},
+		{4.5, 9.4, 51, 100},
+		{9.5, 12.4, 101, 150},
+		{12.5, 15.4, 151, 200},
+		{15.5, 30.4, 201, 300},
+		{30.5, 50.4, 301, 500},
+	}}
+	// SO2 is the 1 hour sulfur dioxide table in ppb, above 200 it is based on the 24 hour average
+	SO2 = Table{Precision: 0, Breakpoints: []Breakpoint{
+		{0, 35, 0, 50},
+		{36, 75, 51, 100},
+		{76, 185, 101, 150},
+		{186, 304, 151, 200},
+		{305, 604, 201, 300},
+		{605, 1004, 301, 500},
+	}}
+	// NO2 is the 1 hour nitrogen dioxide table in ppb
+	NO2 = Table{Precision: 0, Breakpoints: []Breakpoint{
+		{0, 53, 0, 50},
+		{54, 100, 51, 100},
+		{101, 360, 101, 150},
+		{361, 649, 151, 200},
+		{650, 1249, 201, 300},
+		{1250, 2049, 301, 500},
+	}}
+)
+
+// AQI returns the aqi for the concentration, and false if the table does not cover it
+func (t Table) AQI(conc float64) (int, bool) {
+	if math.IsNaN(conc) || conc < 0 || len(t.Breakpoints) == 0 {
+		return 0, false
+	}
+	c := truncate(conc, t.Precision)
+	if c < t.Breakpoints[0].Low {
+		return 0, false
+	}
+	last := t.Breakpoints[len(t.Breakpoints)-1]
+	if c > last.High {
+		if last.IndexHigh < MaxAQI {
+			return 0, false
+		}
+		return MaxAQI, true
+	}
+	for i, bp := range t.Breakpoints {
+		if c <= bp.High {
+			return linear(bp, c), true
+		}
+		// concentrations truncated into the gap between breakpoints belong to the higher one
+		if i+1 < len(t.Breakpoints) && c < t.Breakpoints[i+1].Low {
+			next := t.Breakpoints[i+1]
+			return linear(next, next.Low), true
+		}
+	}
+	return 0, false
+}
+
+func linear(bp Breakpoint, c float64) int {
+	if bp.High == bp.Low {
+		return bp.IndexHigh
+	}
+	i := float64(bp.IndexHigh-bp.IndexLow)/(bp.High-bp.Low)*(c-bp.Low) + float64(bp.IndexLow)
+	return int(math.Round(i))
+}
+
+func truncate(v float64, precision int) float64 {
+	scale := math.Pow(10, float64(precision))
+	return math.Floor(v*scale+1e-9) / scale
+}
+
+// PM25AQI returns the aqi for a pm2.5 concentration in ug/m3
+func PM25AQI(ugm3 float64) (int, bool) {
+	return PM25.AQI(ugm3)
+}
+
+// PM10AQI returns the aqi for a pm10 concentration in ug/m3
+func PM10AQI(ugm3 float64) (int, bool) {
+	return PM10.AQI(ugm3)
+}
+
+// o3EightHourBeyondTable is the aqi of an 8 hour ozone concentration above the 8 hour table, the bottom of hazardous
+const o3EightHourBeyondTable = 301
+
+// O3AQI returns the aqi for 8 hour and 1 hour ozone concentrations in ppb, the higher of the two is used.
+// Either may be NaN when it is not known.
+func O3AQI(eightHourPPB, oneHourPPB float64) (int, bool) {
+	eight, eightOK := O3EightHour.AQI(eightHourPPB / 1000)
+	one, oneOK := O3OneHour.AQI(oneHourPPB / 1000)
+	if !eightOK && !oneOK {
+		// the 8 hour table stops at 300, above which only the 1 hour table gives the aqi,
+		// without a 1 hour concentration all that is known is that it is hazardous
+		if !math.IsNaN(eightHourPPB) && math.IsNaN(oneHourPPB) && eightHourPPB/1000 > O3EightHour.Breakpoints[len(O3EightHour.Breakpoints)-1].High {
+			return o3EightHourBeyondTable, true
+		}
+		return 0, false
+	}
+	if one > eight {
+		return one, oneOK
+	}
+	return eight, eightOK
+}
+
+// COAQI returns the aqi for a carbon monoxide concentration in ppm
+func COAQI(ppm float64) (int, bool) {
+	return CO.AQI(ppm)
+}
+
+// SO2AQI returns the aqi for a sulfur dioxide concentration in ppb
+func SO2AQI(ppb float64) (int, bool) {
+	return SO2.AQI(ppb)
+}
+
+// NO2AQI returns the aqi for a nitrogen dioxide concentration in ppb
+func NO2AQI(ppb float64) (int, bool) {
+	return NO2.AQI(ppb)
+}
+
+// PollutantAQI returns the aqi for the pollutant's concentration in the units airvisual reports it in.
+// Ozone is treated as an 8 hour average, falling back to the 1 hour table when it is too high.
+func PollutantAQI(pollutant airvisual.Pollutant, conc float64) (int, bool) {
+	switch pollutant {
+	case airvisual.PollutantPM25:
+		return PM25AQI(conc)
+	case airvisual.PollutantPM10:
+		return PM10AQI(conc)
+	case airvisual.PollutantO3:
+		return O3AQI(conc, math.NaN())
+	case airvisual.PollutantCO:
+		return COAQI(conc)
+	case airvisual.PollutantSO2:
+		return SO2AQI(conc)
+	case airvisual.PollutantNO2:
+		return NO2AQI(conc)
+	}
+	return 0, false
+}
+
+// Pollutants are the pollutants the epa aqi is computed from
+var Pollutants = []airvisual.Pollutant{
+	airvisual.PollutantPM25,
+	airvisual.PollutantPM10,
+	airvisual.PollutantO3,
+	airvisual.PollutantNO2,
+	airvisual.PollutantSO2,
+	airvisual.PollutantCO,
+}
+
+// Normalize sets the aqi of each measured pollutant and the overall aqi and main pollutant
+// from the concentrations, it returns false if no concentration could be converted
+func Normalize(p *airvisual.Pollution) bool {
+	found := false
+	for _, pollutant := range Pollutants {
+		conc := p.Concentration(pollutant)
+		if conc == nil {
+			continue
+		}
+		aqi, ok := PollutantAQI(pollutant, conc.Value)
+		if !ok {
+			continue
+		}
+		conc.AQI = aqi
+		if !found || aqi > p.AQI {
+			p.AQI = aqi
+			p.MainPollutant = pollutant
+		}
+		found = true
+	}
+	return found
+}
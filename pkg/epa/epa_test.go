@@ -0,0 +1,131 @@
+package epa
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/mat285/aqi/pkg/airvisual"
+)
+
+func TestTableAQI(t *testing.T) {
+	cases := []struct {
+		name  string
+		table Table
+		conc  float64
+		aqi   int
+		ok    bool
+	}{
+		{"pm25 zero", PM25, 0, 0, true},
+		{"pm25 good top", PM25, 9.0, 50, true},
+		{"pm25 truncated into good", PM25, 9.09, 50, true},
+		{"pm25 moderate bottom", PM25, 9.1, 51, true},
+		{"pm25 moderate top", PM25, 35.4, 100, true},
+		{"pm25 sensitive bottom", PM25, 35.5, 101, true},
+		{"pm25 unhealthy top", PM25, 125.4, 200, true},
+		{"pm25 very unhealthy top", PM25, 225.4, 300, true},
+		{"pm25 hazardous bottom", PM25, 225.5, 301, true},
+		{"pm25 hazardous top", PM25, 325.4, 500, true},
+		{"pm25 beyond the table", PM25, 600, MaxAQI, true},
+		{"pm25 negative", PM25, -1, 0, false},
+		{"pm25 nan", PM25, math.NaN(), 0, false},
+		{"pm10 good top", PM10, 54, 50, true},
+		{"pm10 truncated into good", PM10, 54.9, 50, true},
+		{"pm10 moderate bottom", PM10, 55, 51, true},
+		{"co moderate bottom", CO, 4.5, 51, true},
+		{"o3 8 hour beyond the table", O3EightHour, 0.201, 0, false},
+		{"o3 1 hour below the table", O3OneHour, 0.1, 0, false},
+		{"o3 1 hour bottom", O3OneHour, 0.125, 101, true},
+	}
+	for _, c := range cases {
+		aqi, ok := c.table.AQI(c.conc)
+		if aqi != c.aqi || ok != c.ok {
+			t.Errorf("%s: AQI(%v) = %d, %v, want %d, %v", c.name, c.conc, aqi, ok, c.aqi, c.ok)
+		}
+	}
+}
+
+func TestTableAQIGap(t *testing.T) {
+	// finer precision than the breakpoints leaves concentrations between them, which belong to the higher one
+	table := Table{Precision: 2, Breakpoints: []Breakpoint{
+		{0.0, 9.0, 0, 50},
+		{9.1, 35.4, 51, 100},
+	}}
+	aqi, ok := table.AQI(9.05)
+	if aqi != 51 || !ok {
+		t.Errorf("AQI(9.05) = %d, %v, want 51, true", aqi, ok)
+	}
+}
+
+func TestO3AQI(t *testing.T) {
+	cases := []struct {
+		eight, one float64
+		aqi        int
+		ok         bool
+	}{
+		{54, math.NaN(), 50, true},
+		{math.NaN(), 125, 101, true},
+		{60, 170, 157, true},
+		{200, math.NaN(), 300, true},
+		{201, math.NaN(), 301, true},
+		{205, math.NaN(), 301, true},
+		{250, math.NaN(), 301, true},
+		{201, 420, 316, true},
+		{math.NaN(), math.NaN(), 0, false},
+	}
+	for _, c := range cases {
+		aqi, ok := O3AQI(c.eight, c.one)
+		if aqi != c.aqi || ok != c.ok {
+			t.Errorf("O3AQI(%v, %v) = %d, %v, want %d, %v", c.eight, c.one, aqi, ok, c.aqi, c.ok)
+		}
+	}
+}
+
+func TestNowCast(t *testing.T) {
+	nan := math.NaN()
+	cases := []struct {
+		name   string
+		hourly []float64
+		conc   float64
+		ok     bool
+	}{
+		{"steady", []float64{10, 10, 10}, 10, true},
+		{"weighted to the recent hour", []float64{20, 10}, 25.0 / 1.5, true},
+		{"weight floored at half", []float64{40, 10}, 45.0 / 1.5, true},
+		{"missing hour skipped", []float64{10, nan, 10}, 10, true},
+		{"too few recent hours", []float64{10, nan, nan, 10}, 0, false},
+		{"empty", nil, 0, false},
+	}
+	for _, c := range cases {
+		conc, ok := NowCast(c.hourly)
+		if ok != c.ok || math.Abs(conc-c.conc) > 1e-9 {
+			t.Errorf("%s: NowCast(%v) = %v, %v, want %v, %v", c.name, c.hourly, conc, ok, c.conc, c.ok)
+		}
+	}
+}
+
+func TestNormalizeDataNowCast(t *testing.T) {
+	now := time.Date(2024, 9, 10, 10, 30, 0, 0, time.UTC)
+	d := &airvisual.Data{}
+	d.Current.Pollution = airvisual.Pollution{Time: now, PM25: &airvisual.Concentration{Value: 40}}
+	d.History.Pollution = []airvisual.Pollution{
+		{Time: now.Add(-time.Hour), PM25: &airvisual.Concentration{Value: 20}},
+		{Time: now.Add(-2 * time.Hour), PM25: &airvisual.Concentration{Value: 20}},
+	}
+	if !NormalizeData(d, now) {
+		t.Fatal("NormalizeData() = false, want true")
+	}
+	// the nowcast of 40, 20, 20 weighs by half each hour, (40 + 10 + 5) / 1.75
+	want, _ := PM25AQI(55 / 1.75)
+	if p := d.Current.Pollution; p.AQI != want || p.MainPollutant != airvisual.PollutantPM25 {
+		t.Errorf("aqi = %d %s, want %d %s", p.AQI, p.MainPollutant, want, airvisual.PollutantPM25)
+	}
+
+	d.History.Pollution = nil
+	if !NormalizeData(d, now) {
+		t.Fatal("NormalizeData() = false, want true")
+	}
+	if want, _ := PM25AQI(40); d.Current.Pollution.AQI != want {
+		t.Errorf("aqi without history = %d, want %d", d.Current.Pollution.AQI, want)
+	}
+}
@@ -0,0 +1,112 @@
+package epa
+
+import (
+	"math"
+	"time"
+
+	"github.com/mat285/aqi/pkg/airvisual"
+)
+
+const (
+	// NowCastHours is the number of hours of concentrations the nowcast considers
+	NowCastHours = 12
+	// NowCastMinWeight is the minimum weight factor for particulates
+	NowCastMinWeight = 0.5
+)
+
+// NowCast returns the nowcast concentration for particulates from hourly concentrations,
+// most recent hour first, with NaN for missing hours. At least two of the three most recent
+// hours must be present.
+func NowCast(hourly []float64) (float64, bool) {
+	if len(hourly) > NowCastHours {
+		hourly = hourly[:NowCastHours]
+	}
+	recent := 0
+	for i := 0; i < len(hourly) && i < 3; i++ {
+		if !math.IsNaN(hourly[i]) {
+			recent++
+		}
+	}
+	if recent < 2 {
+		return 0, false
+	}
+	min, max := math.Inf(1), math.Inf(-1)
+	for _, c := range hourly {
+		if math.IsNaN(c) {
+			continue
+		}
+		min = math.Min(min, c)
+		max = math.Max(max, c)
+	}
+	weight := NowCastMinWeight
+	if max > 0 {
+		weight = math.Max(min/max, NowCastMinWeight)
+	}
+	var sum, weights float64
+	factor := 1.0
+	for _, c := range hourly {
+		if !math.IsNaN(c) {
+			sum += factor * c
+			weights += factor
+		}
+		factor *= weight
+	}
+	return sum / weights, true
+}
+
+// NowCastPM25AQI returns the aqi of the pm2.5 nowcast for the hourly concentrations
+func NowCastPM25AQI(hourly []float64) (int, bool) {
+	conc, ok := NowCast(hourly)
+	if !ok {
+		return 0, false
+	}
+	return PM25AQI(conc)
+}
+
+// HourlyConcentrations returns the concentrations of the pollutant for each of the hours before now,
+// most recent first, with NaN for hours with no reading
+func HourlyConcentrations(history []airvisual.Pollution, pollutant airvisual.Pollutant, now time.Time, hours int) []float64 {
+	ret := make([]float64, hours)
+	for i := range ret {
+		ret[i] = math.NaN()
+	}
+	end := now.Truncate(time.Hour)
+	for _, p := range history {
+		conc := p.Concentration(pollutant)
+		if conc == nil {
+			continue
+		}
+		i := int(end.Sub(p.Time.Truncate(time.Hour)) / time.Hour)
+		if i < 0 || i >= hours {
+			continue
+		}
+		ret[i] = conc.Value
+	}
+	return ret
+}
+
+// NormalizeData normalizes the current pollution of the data like Normalize, except pm2.5 uses the nowcast
+// of the hourly history when the data has enough of it, as airvisual returns on paid plans, rather than the
+// 24 hour table applied to the latest concentration
+func NormalizeData(d *airvisual.Data, now time.Time) bool {
+	p := &d.Current.Pollution
+	if !Normalize(p) {
+		return false
+	}
+	if p.PM25 == nil {
+		return true
+	}
+	hourly := append([]airvisual.Pollution{*p}, d.History.Pollution...)
+	aqi, ok := NowCastPM25AQI(HourlyConcentrations(hourly, airvisual.PollutantPM25, now, NowCastHours))
+	if !ok {
+		return true
+	}
+	p.PM25.AQI = aqi
+	p.AQI, p.MainPollutant = 0, ""
+	for _, pollutant := range Pollutants {
+		if conc := p.Concentration(pollutant); conc != nil && conc.AQI > p.AQI {
+			p.AQI, p.MainPollutant = conc.AQI, pollutant
+		}
+	}
+	return true
+}
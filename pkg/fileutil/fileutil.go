@@ -0,0 +1,29 @@
+package fileutil
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	exception "github.com/blend/go-sdk/exception"
+)
+
+// WriteAtomic writes the data to a temp file beside the file and renames it over the file,
+// so readers in other processes never see a partly written file
+func WriteAtomic(path string, data []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return exception.New(err)
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if err != nil {
+		tmp.Close()
+		return exception.New(err)
+	}
+	err = tmp.Close()
+	if err != nil {
+		return exception.New(err)
+	}
+	return exception.New(os.Rename(tmp.Name(), path))
+}
@@ -0,0 +1,34 @@
+package fileutil
+
+import (
+	"os"
+	"syscall"
+
+	exception "github.com/blend/go-sdk/exception"
+)
+
+// LockSuffix is appended to the name of a file for the lock file beside it
+const LockSuffix = ".lock"
+
+// Lock takes an exclusive lock on the lock file beside the file, waiting for other processes
+// to release it, and returns the function that releases it
+func Lock(path string) (func(), error) {
+	f, err := os.OpenFile(path+LockSuffix, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, exception.New(err)
+	}
+	for {
+		err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX)
+		if err != syscall.EINTR {
+			break
+		}
+	}
+	if err != nil {
+		f.Close()
+		return nil, exception.New(err)
+	}
+	return func() {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
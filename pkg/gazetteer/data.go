@@ -0,0 +1,211 @@
+package gazetteer
+
+import (
+	"bufio"
+	"embed"
+	"io"
+	"io/fs"
+	"strconv"
+	"strings"
+
+	exception "github.com/blend/go-sdk/exception"
+)
+
+const (
+	// ErrInvalidData is returned when a gazetteer data file cannot be parsed
+	ErrInvalidData exception.Class = "InvalidGazetteerData"
+
+	// CountriesFile is the geonames countryInfo.txt table of countries
+	CountriesFile = "countryInfo.txt"
+	// RegionsFile is the geonames admin1CodesASCII.txt table of first level administrative regions
+	RegionsFile = "admin1CodesASCII.txt"
+	// CitiesFile is a geonames table of cities, like an extract of cities15000.txt
+	CitiesFile = "cities.txt"
+	// ZipsFile is the census gazetteer table of us zip code tabulation areas, it is optional
+	ZipsFile = "zcta.txt"
+	// ZipPrefixesFile lists the cities in the zip codes with each three digit prefix, tab separated as
+	// `<prefix> <country code> <admin1 code> <city>`, for zip codes missing from the zip code table.
+	// Cities not in the gazetteer are skipped
+	ZipPrefixesFile = "zip3.txt"
+)
+
+//go:generate ./update.sh
+
+//go:embed data
+var data embed.FS
+
+// Default is the gazetteer loaded from the data bundled in `data`
+var Default = mustLoadBundled()
+
+func mustLoadBundled() *Gazetteer {
+	bundled, err := fs.Sub(data, "data")
+	if err != nil {
+		panic(err)
+	}
+	g, err := Load(bundled)
+	if err != nil {
+		panic(err)
+	}
+	return g
+}
+
+// Load loads a gazetteer from the data files in the file system
+func Load(fsys fs.FS) (*Gazetteer, error) {
+	g := &Gazetteer{byName: map[string][]int{}, zips: map[string]coordinates{}, prefixes: map[string][]int{}}
+	countries := map[string]named{}
+	err := readTable(fsys, CountriesFile, 5, func(f []string) error {
+		name := f[4]
+		if override, ok := countryNames[f[0]]; ok {
+			name = override
+		}
+		countries[f[0]] = named{name, uniqueNames(append([]string{name, f[4], f[0], f[1]}, countryAliases[Normalize(name)]...))}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	regions := map[string]named{}
+	err = readTable(fsys, RegionsFile, 3, func(f []string) error {
+		name := f[2]
+		if len(name) == 0 {
+			name = f[1]
+		}
+		names := append([]string{name}, regionAliases[Normalize(name)]...)
+		if strings.HasPrefix(f[0], "US.") {
+			// us regions are coded by their postal abbreviations
+			names = append(names, strings.TrimPrefix(f[0], "US."))
+		}
+		regions[f[0]] = named{name, uniqueNames(names)}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = readTable(fsys, CitiesFile, 15, func(f []string) error {
+		country, ok := countries[f[8]]
+		if !ok {
+			return exception.New(ErrInvalidData).WithMessagef("%s: unknown country `%s`", CitiesFile, f[8])
+		}
+		region, ok := regions[f[8]+"."+f[10]]
+		if !ok {
+			// airvisual names every city by its region, so cities without a known one can't be looked up
+			return nil
+		}
+		lat, latErr := strconv.ParseFloat(f[4], 64)
+		lon, lonErr := strconv.ParseFloat(f[5], 64)
+		population, popErr := strconv.Atoi(f[14])
+		if latErr != nil || lonErr != nil || popErr != nil {
+			return exception.New(ErrInvalidData).WithMessagef("%s: invalid city `%s`", CitiesFile, f[1])
+		}
+		p := Place{
+			City:       f[2],
+			Region:     region.name,
+			Country:    country.name,
+			Latitude:   lat,
+			Longitude:  lon,
+			Population: population,
+			regions:    region.names,
+			countries:  country.names,
+		}
+		if len(p.City) == 0 {
+			p.City = f[1]
+		}
+		p.Aliases = cityAliases[p.String()]
+		g.add(p)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fs.Stat(fsys, ZipsFile); err == nil {
+		err = readTable(fsys, ZipsFile, 7, func(f []string) error {
+			if f[0] == "GEOID" {
+				return nil
+			}
+			lat, latErr := strconv.ParseFloat(strings.TrimSpace(f[5]), 64)
+			lon, lonErr := strconv.ParseFloat(strings.TrimSpace(f[6]), 64)
+			if latErr != nil || lonErr != nil {
+				return exception.New(ErrInvalidData).WithMessagef("%s: invalid zip code `%s`", ZipsFile, f[0])
+			}
+			g.zips[f[0]] = coordinates{Latitude: lat, Longitude: lon}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = readTable(fsys, ZipPrefixesFile, 4, func(f []string) error {
+		region, ok := regions[f[1]+"."+f[2]]
+		if !ok {
+			return exception.New(ErrInvalidData).WithMessagef("%s: unknown region `%s.%s`", ZipPrefixesFile, f[1], f[2])
+		}
+		for _, index := range g.byName[Normalize(f[3])] {
+			if g.places[index].Region == region.name {
+				g.prefixes[f[0]] = append(g.prefixes[f[0]], index)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// add adds the place and indexes it by its names
+func (g *Gazetteer) add(p Place) {
+	g.places = append(g.places, p)
+	for _, name := range uniqueNames(p.Names()) {
+		g.byName[name] = append(g.byName[name], len(g.places)-1)
+	}
+}
+
+// readTable calls the handler with the fields of each line of the tab separated file,
+// skipping blank lines and comments and requiring the number of fields
+func readTable(fsys fs.FS, name string, fields int, handler func([]string) error) error {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return exception.New(err)
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return exception.New(err)
+		}
+		if trimmed := strings.TrimRight(line, "\r\n"); len(strings.TrimSpace(trimmed)) > 0 && !strings.HasPrefix(trimmed, "#") {
+			row := strings.Split(trimmed, "\t")
+			if len(row) < fields {
+				return exception.New(ErrInvalidData).WithMessagef("%s: expected %d fields in `%s`", name, fields, trimmed)
+			}
+			if herr := handler(row); herr != nil {
+				return herr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
+
+// named is a region or country with the normalized names it can be looked up by
+type named struct {
+	name  string
+	names []string
+}
+
+// uniqueNames returns the names normalized without duplicates
+func uniqueNames(names []string) []string {
+	ret := []string{}
+	seen := map[string]bool{}
+	for _, name := range names {
+		normalized := Normalize(name)
+		if len(normalized) == 0 || seen[normalized] {
+			continue
+		}
+		seen[normalized] = true
+		ret = append(ret, normalized)
+	}
+	return ret
+}
@@ -0,0 +1,223 @@
+package gazetteer
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// CountryUSA is how airvisual names the us
+const CountryUSA = "USA"
+
+// Place is a city in the gazetteer, named as airvisual names it
+type Place struct {
+	City       string
+	Region     string
+	Country    string
+	Aliases    []string
+	Latitude   float64
+	Longitude  float64
+	Population int
+
+	// regions and countries are the normalized names of the region and country with their aliases
+	regions   []string
+	countries []string
+}
+
+// String returns the full name of the place
+func (p Place) String() string {
+	return fmt.Sprintf("%s, %s, %s", p.City, p.Region, p.Country)
+}
+
+// Names returns the normalized names the place can be looked up by
+func (p Place) Names() []string {
+	names := []string{Normalize(p.City)}
+	for _, a := range p.Aliases {
+		names = append(names, Normalize(a))
+	}
+	return names
+}
+
+// Qualifies returns if the normalized text names the region or country of the place, or both in that order
+func (p Place) Qualifies(text string) bool {
+	for _, c := range p.countries {
+		if text == c {
+			return true
+		}
+	}
+	for _, r := range p.regions {
+		if text == r {
+			return true
+		}
+		for _, c := range p.countries {
+			if text == r+" "+c {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Matches returns if the normalized text is a name of the place, optionally followed by its region or country
+func (p Place) Matches(text string) bool {
+	for _, name := range p.Names() {
+		if text == name {
+			return true
+		}
+		if strings.HasPrefix(text, name+" ") && p.Qualifies(strings.TrimPrefix(text, name+" ")) {
+			return true
+		}
+	}
+	return false
+}
+
+// Gazetteer looks up places by name or us zip code
+type Gazetteer struct {
+	places []Place
+	// byName are the indexes of the places by each of their names
+	byName map[string][]int
+	// zips are the centroids of us zip codes
+	zips map[string]coordinates
+	// prefixes are the indexes of the places in the zip codes with each three digit prefix
+	prefixes map[string][]int
+}
+
+type coordinates struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// Places returns every place in the gazetteer
+func (g *Gazetteer) Places() []Place {
+	return g.places
+}
+
+// Search returns the places the text could name, most populous first
+func (g *Gazetteer) Search(text string) []Place {
+	text = Normalize(text)
+	if len(text) == 0 {
+		return nil
+	}
+	if IsPostalCode(text) {
+		return g.searchPostalCode(text[:5])
+	}
+	words := strings.Fields(text)
+	found := map[int]bool{}
+	ret := []Place{}
+	for i := len(words); i > 0; i-- {
+		name, rest := strings.Join(words[:i], " "), strings.Join(words[i:], " ")
+		for _, index := range g.byName[name] {
+			if found[index] || len(rest) > 0 && !g.places[index].Qualifies(rest) {
+				continue
+			}
+			found[index] = true
+			ret = append(ret, g.places[index])
+		}
+	}
+	sortByPopulation(ret)
+	return ret
+}
+
+// searchPostalCode returns the place nearest the centroid of the zip code, at the centroid,
+// or the places with the zip code's prefix if its centroid is not known
+func (g *Gazetteer) searchPostalCode(code string) []Place {
+	if c, ok := g.zips[code]; ok {
+		nearest, distance := -1, math.Inf(1)
+		for i, p := range g.places {
+			if p.Country != CountryUSA {
+				continue
+			}
+			if d := distanceKm(c, coordinates{p.Latitude, p.Longitude}); d < distance {
+				nearest, distance = i, d
+			}
+		}
+		if nearest >= 0 {
+			p := g.places[nearest]
+			p.Latitude, p.Longitude = c.Latitude, c.Longitude
+			return []Place{p}
+		}
+	}
+	ret := []Place{}
+	for _, index := range g.prefixes[code[:3]] {
+		ret = append(ret, g.places[index])
+	}
+	sortByPopulation(ret)
+	return ret
+}
+
+func sortByPopulation(places []Place) {
+	sort.SliceStable(places, func(i, j int) bool {
+		return places[i].Population > places[j].Population
+	})
+}
+
+// distanceKm returns the great circle distance between the coordinates
+func distanceKm(a, b coordinates) float64 {
+	const earthRadiusKm = 6371
+	lat1, lat2 := a.Latitude*math.Pi/180, b.Latitude*math.Pi/180
+	dLat, dLon := lat2-lat1, (b.Longitude-a.Longitude)*math.Pi/180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
+
+// Search returns the places in the bundled gazetteer the text could name, most populous first
+func Search(text string) []Place {
+	return Default.Search(text)
+}
+
+// Normalize lowercases the text, drops punctuation and collapses whitespace
+func Normalize(text string) string {
+	text = strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r), r == '-':
+			return unicode.ToLower(r)
+		case r == '\'':
+			return -1
+		}
+		return ' '
+	}, text)
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// IsPostalCode returns if the normalized text is a us zip code, with or without the plus four
+func IsPostalCode(text string) bool {
+	if len(text) != 5 && len(text) != 10 {
+		return false
+	}
+	for i, r := range text {
+		if i == 5 && r == '-' {
+			continue
+		}
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// Qualify returns the shortest text that names only the place among the matches, the city with its state
+// in the us and its country elsewhere, the city with the other of the two, or its full name
+func Qualify(p Place, matches []Place) string {
+	qualifiers := []string{p.Country, p.Region}
+	if p.Country == CountryUSA {
+		qualifiers = []string{p.Region, p.Country}
+	}
+	for _, text := range []string{
+		p.City,
+		fmt.Sprintf("%s, %s", p.City, qualifiers[0]),
+		fmt.Sprintf("%s, %s", p.City, qualifiers[1]),
+	} {
+		count := 0
+		for _, m := range matches {
+			if m.Matches(Normalize(text)) {
+				count++
+			}
+		}
+		if count == 1 {
+			return text
+		}
+	}
+	return p.String()
+}
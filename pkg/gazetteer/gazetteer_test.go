@@ -0,0 +1,158 @@
+package gazetteer
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func names(places []Place) string {
+	ret := []string{}
+	for _, p := range places {
+		ret = append(ret, p.String())
+	}
+	return strings.Join(ret, "; ")
+}
+
+func TestSearch(t *testing.T) {
+	cases := []struct {
+		text     string
+		expected string
+	}{
+		{"", ""},
+		{"lasagna", ""},
+		{"sf", "San Francisco, California, USA"},
+		{"San Francisco", "San Francisco, California, USA"},
+		{"san francisco, ca", "San Francisco, California, USA"},
+		{"portland", "Portland, Oregon, USA; Portland, Maine, USA"},
+		{"portland oregon", "Portland, Oregon, USA"},
+		{"portland or", "Portland, Oregon, USA"},
+		{"Portland, ME, USA", "Portland, Maine, USA"},
+		{"portland france", ""},
+		{"paris", "Paris, Ile-de-France, France; Paris, Texas, USA"},
+		{"paris fr", "Paris, Ile-de-France, France"},
+		{"paris, tx", "Paris, Texas, USA"},
+		{"london", "London, England, United Kingdom; London, Ontario, Canada"},
+		{"london uk", "London, England, United Kingdom"},
+		{"london gb", "London, England, United Kingdom"},
+		{"london canada", "London, Ontario, Canada"},
+		{"nyc", "New York, New York, USA"},
+		{"new york ny", "New York, New York, USA"},
+		{"washington dc", "Washington, District of Columbia, USA"},
+		{"vancouver bc", "Vancouver, British Columbia, Canada"},
+		{"94110", "San Francisco, California, USA"},
+		{"94110-1234", "San Francisco, California, USA"},
+		{"10001", "New York, New York, USA"},
+		{"99999", ""},
+	}
+	for _, c := range cases {
+		if found := names(Search(c.text)); found != c.expected {
+			t.Errorf("Search(%q) = %q, expected %q", c.text, found, c.expected)
+		}
+	}
+}
+
+func TestQualify(t *testing.T) {
+	cases := []struct {
+		text     string
+		expected []string
+	}{
+		{"paris", []string{"Paris, France", "Paris, Texas"}},
+		{"london", []string{"London, United Kingdom", "London, Canada"}},
+		{"springfield", []string{"Springfield, Missouri", "Springfield, Massachusetts", "Springfield, Illinois"}},
+	}
+	for _, c := range cases {
+		matches := Search(c.text)
+		if len(matches) != len(c.expected) {
+			t.Errorf("Search(%q) = %q, expected %d places", c.text, names(matches), len(c.expected))
+			continue
+		}
+		for i, p := range matches {
+			if q := Qualify(p, matches); q != c.expected[i] {
+				t.Errorf("Qualify(%s) = %q, expected %q", p, q, c.expected[i])
+			}
+			if found := Search(Qualify(p, matches)); len(found) != 1 || found[0].String() != p.String() {
+				t.Errorf("Search(%q) = %q, expected %s", Qualify(p, matches), names(found), p)
+			}
+		}
+	}
+}
+
+var fixture = fstest.MapFS{
+	CountriesFile: {Data: []byte("# ISO\tISO3\tISO-Numeric\tfips\tCountry\tCapital\n" +
+		"US\tUSA\t840\tUS\tUnited States\tWashington\n" +
+		"FR\tFRA\t250\tFR\tFrance\tParis\n")},
+	RegionsFile: {Data: []byte("US.CA\tCalifornia\tCalifornia\t5332921\n" +
+		"US.TX\tTexas\tTexas\t4736286\n" +
+		"FR.11\tÎle-de-France\tIle-de-France\t3012874\n")},
+	CitiesFile: {Data: []byte(
+		"5391959\tSan Francisco\tSan Francisco\tSF,San Francisco\t37.77493\t-122.41942\tP\tPPLA2\tUS\t\tCA\t075\t\t\t864816\t16\t28\tAmerica/Los_Angeles\t2022-01-01\n" +
+			"5392171\tSan Jose\tSan Jose\t\t37.33939\t-121.89496\tP\tPPLA2\tUS\t\tCA\t085\t\t\t1026908\t26\t27\tAmerica/Los_Angeles\t2022-01-01\n" +
+			"4717560\tParis\tParis\t\t33.66094\t-95.55551\tP\tPPLA2\tUS\t\tTX\t277\t\t\t25171\t177\t180\tAmerica/Chicago\t2022-01-01\n" +
+			"2988507\tParis\tParis\t\t48.85341\t2.3488\tP\tPPLC\tFR\t\t11\t75\t\t\t2138551\t\t42\tEurope/Paris\t2022-01-01\n" +
+			"2972315\tNowhere\tNowhere\t\t43.6\t1.44\tP\tPPL\tFR\t\t76\t31\t\t\t471941\t\t150\tEurope/Paris\t2022-01-01\n")},
+	ZipsFile: {Data: []byte("GEOID\tALAND\tAWATER\tALAND_SQMI\tAWATER_SQMI\tINTPTLAT\tINTPTLONG                                                                                                               \n" +
+		"95112\t11766765\t0\t4.543\t0.000\t37.346195\t-121.888434                 \n" +
+		"94110\t6009225\t0\t2.320\t0.000\t37.750021\t-122.415201                 \n")},
+	ZipPrefixesFile: {Data: []byte("941\tUS\tCA\tSan Francisco\n" +
+		"951\tUS\tCA\tSan Jose\n" +
+		"750\tUS\tTX\tDallas\n")},
+}
+
+func TestLoad(t *testing.T) {
+	g, err := Load(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Places()) != 4 {
+		t.Errorf("expected the cities with known regions to be loaded, got %q", names(g.Places()))
+	}
+	cases := []struct {
+		text     string
+		expected string
+	}{
+		{"sf", "San Francisco, California, USA"},
+		{"paris", "Paris, Ile-de-France, France; Paris, Texas, USA"},
+		{"paris usa", "Paris, Texas, USA"},
+		{"paris united states", "Paris, Texas, USA"},
+		{"paris fra", "Paris, Ile-de-France, France"},
+		{"nowhere", ""},
+		{"95112", "San Jose, California, USA"},
+		{"94110", "San Francisco, California, USA"},
+		{"94133", "San Francisco, California, USA"},
+		{"75001", ""},
+	}
+	for _, c := range cases {
+		if found := names(g.Search(c.text)); found != c.expected {
+			t.Errorf("Search(%q) = %q, expected %q", c.text, found, c.expected)
+		}
+	}
+
+	zip := g.Search("95112")[0]
+	if math.Abs(zip.Latitude-37.346195) > 1e-9 || math.Abs(zip.Longitude+121.888434) > 1e-9 {
+		t.Errorf("expected a zip code's place at its centroid, got %f,%f", zip.Latitude, zip.Longitude)
+	}
+	prefix := g.Search("94133")[0]
+	if math.Abs(prefix.Latitude-37.77493) > 1e-9 {
+		t.Errorf("expected a zip code without a centroid at its city, got %f,%f", prefix.Latitude, prefix.Longitude)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	for name, data := range map[string]string{
+		CountriesFile: "US\tUSA\n",
+		RegionsFile:   "US.CA\n",
+		CitiesFile:    "1\tSan Francisco\tSan Francisco\t\tnorth\t-122.4\tP\tPPL\tUS\t\tCA\t\t\t\t864816\n",
+		ZipsFile:      "94110\t0\t0\t0\t0\tnorth\twest\n",
+	} {
+		invalid := fstest.MapFS{}
+		for n, f := range fixture {
+			invalid[n] = f
+		}
+		invalid[name] = &fstest.MapFile{Data: []byte(data)}
+		if _, err := Load(invalid); err == nil {
+			t.Errorf("expected an error loading an invalid %s", name)
+		}
+	}
+}
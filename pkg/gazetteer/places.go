@@ -0,0 +1,52 @@
+package gazetteer
+
+var (
+	// countryNames are the names airvisual uses for countries that differ from their geonames names, keyed by country code
+	countryNames = map[string]string{
+		"US": CountryUSA,
+	}
+
+	// regionAliases are the abbreviations and other names of regions beyond their names and, in the us,
+	// their postal abbreviations, keyed by their normalized name
+	regionAliases = map[string][]string{
+		"british columbia": {"bc"},
+		"california":       {"calif"},
+		"massachusetts":    {"mass"},
+		"new south wales":  {"nsw"},
+		"ontario":          {"on"},
+		"quebec":           {"qc"},
+		"victoria":         {"vic"},
+	}
+
+	// countryAliases are the other names of countries beyond their names and codes, keyed by their normalized name
+	countryAliases = map[string][]string{
+		"usa":            {"united states of america", "america"},
+		"united kingdom": {"uk", "great britain", "britain"},
+		"netherlands":    {"the netherlands", "holland"},
+		"south korea":    {"korea"},
+	}
+
+	// cityAliases are the nicknames and other names of cities, keyed by their full names
+	cityAliases = map[string][]string{
+		"San Francisco, California, USA":        {"sf", "sfo", "frisco"},
+		"San Jose, California, USA":             {"sj"},
+		"Los Angeles, California, USA":          {"la"},
+		"Portland, Oregon, USA":                 {"pdx"},
+		"New York, New York, USA":               {"nyc", "new york city", "ny"},
+		"New York City, New York, USA":          {"nyc", "new york", "ny"},
+		"Chicago, Illinois, USA":                {"chi"},
+		"Washington, District of Columbia, USA": {"dc", "washington dc"},
+		"Philadelphia, Pennsylvania, USA":       {"philly"},
+		"Atlanta, Georgia, USA":                 {"atl"},
+		"Salt Lake City, Utah, USA":             {"slc"},
+		"Las Vegas, Nevada, USA":                {"vegas"},
+		"Mexico City, Mexico City, Mexico":      {"cdmx"},
+		"Munich, Bavaria, Germany":              {"munchen"},
+		"Rome, Lazio, Italy":                    {"roma"},
+		"Milan, Lombardy, Italy":                {"milano"},
+		"Delhi, Delhi, India":                   {"new delhi"},
+		"Mumbai, Maharashtra, India":            {"bombay"},
+		"Bengaluru, Karnataka, India":           {"bangalore"},
+		"Beijing, Beijing, China":               {"peking"},
+	}
+)
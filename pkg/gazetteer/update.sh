@@ -0,0 +1,20 @@
+#!/bin/sh
+# update.sh replaces the bundled gazetteer data with the full geonames tables of cities with at least
+# MIN_POPULATION people, 15000 by default, and the census table of zip codes. It needs curl and unzip.
+set -e
+cd "$(dirname "$0")"
+
+MIN_POPULATION=${MIN_POPULATION:-15000}
+GEONAMES=https://download.geonames.org/export/dump
+ZCTA=https://www2.census.gov/geo/docs/maps-data/data/gazetteer/2020_Gazetteer/2020_Gaz_zcta_national.zip
+
+tmp=$(mktemp -d)
+trap 'rm -rf "$tmp"' EXIT
+
+curl -sSfL -o "$tmp/cities.zip" "$GEONAMES/cities$MIN_POPULATION.zip"
+# the alternate names are most of the file and too many to match on, so they are dropped
+unzip -p "$tmp/cities.zip" | awk 'BEGIN { FS = OFS = "\t" } { $4 = ""; print }' > data/cities.txt
+curl -sSfL -o data/admin1CodesASCII.txt "$GEONAMES/admin1CodesASCII.txt"
+curl -sSfL -o data/countryInfo.txt "$GEONAMES/countryInfo.txt"
+curl -sSfL -o "$tmp/zcta.zip" "$ZCTA"
+unzip -p "$tmp/zcta.zip" > data/zcta.txt
@@ -0,0 +1,318 @@
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	exception "github.com/blend/go-sdk/exception"
+	"github.com/mat285/aqi/pkg/airvisual"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	// LockTimeout is how long to wait for another process to finish with the database
+	LockTimeout = 5 * time.Second
+	// LegacySuffix is appended to the name of a json lines history file when it is imported into the database
+	LegacySuffix = ".jsonl"
+)
+
+// Reading is a recorded air quality reading of a location
+type Reading struct {
+	Location  string              `json:"location"`
+	City      string              `json:"city"`
+	State     string              `json:"state"`
+	Country   string              `json:"country"`
+	Time      time.Time           `json:"time"`
+	AQI       int                 `json:"aqi"`
+	Pollutant airvisual.Pollutant `json:"pollutant,omitempty"`
+	Pollution airvisual.Pollution `json:"pollution"`
+	Weather   airvisual.Weather   `json:"weather"`
+}
+
+// NewReading returns the reading of the current air data
+func NewReading(d *airvisual.Data) *Reading {
+	p := d.Current.Pollution
+	t := p.Time
+	if t.IsZero() {
+		t = time.Now().UTC()
+	}
+	return &Reading{
+		Location:  Key(d.City, d.State, d.Country),
+		City:      d.City,
+		State:     d.State,
+		Country:   d.Country,
+		Time:      t,
+		AQI:       p.AQI,
+		Pollutant: p.MainPollutant,
+		Pollution: p,
+		Weather:   d.Current.Weather,
+	}
+}
+
+// Store is a bolt database of readings with a bucket per location keyed by the time of each reading.
+// The database is opened for each operation so the job and the server can share it.
+type Store struct {
+	path string
+	// retention is how long readings are kept, forever if zero
+	retention time.Duration
+	now       func() time.Time
+}
+
+// Open opens the store at the file, creating it if it does not exist. Readings older than the retention
+// are removed as new ones are recorded, they are kept forever if it is zero. A json lines history file
+// from before the store was a database is imported, and kept with LegacySuffix appended to its name.
+func Open(path string, retention time.Duration) (*Store, error) {
+	s := &Store{path: path, retention: retention, now: time.Now}
+	legacy, err := isJSONLines(path)
+	if err != nil {
+		return nil, err
+	}
+	if legacy {
+		err = os.Rename(path, path+LegacySuffix)
+		if err != nil {
+			return nil, exception.New(err)
+		}
+	}
+	err = s.update(func(*bolt.Tx) error { return nil })
+	if err != nil {
+		return nil, err
+	}
+	if legacy {
+		err = s.Import(path + LegacySuffix)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// isJSONLines returns if the file exists and starts with a json object rather than being a database
+func isJSONLines(path string) (bool, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, exception.New(err)
+	}
+	defer f.Close()
+	first := make([]byte, 1)
+	_, err = f.Read(first)
+	if err == io.EOF {
+		return false, nil
+	} else if err != nil {
+		return false, exception.New(err)
+	}
+	return first[0] == '{', nil
+}
+
+// Path returns the path of the store's file
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Record adds the reading to the store unless it is not newer than the last reading of its location,
+// it returns if the reading was recorded
+func (s *Store) Record(r *Reading) (bool, error) {
+	if len(r.Location) == 0 {
+		return false, exception.New("MissingLocation")
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return false, exception.New(err)
+	}
+	recorded := false
+	err = s.update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(r.Location))
+		if err != nil {
+			return err
+		}
+		if last, _ := b.Cursor().Last(); last != nil && !r.Time.After(decodeTime(last)) {
+			return nil
+		}
+		err = b.Put(encodeTime(r.Time), data)
+		if err != nil {
+			return err
+		}
+		recorded = true
+		return s.prune(b)
+	})
+	return recorded, err
+}
+
+// prune removes the readings of the bucket older than the retention
+func (s *Store) prune(b *bolt.Bucket) error {
+	if s.retention <= 0 {
+		return nil
+	}
+	cutoff := encodeTime(s.now().Add(-s.retention))
+	expired := [][]byte{}
+	c := b.Cursor()
+	for k, _ := c.First(); k != nil && bytes.Compare(k, cutoff) < 0; k, _ = c.Next() {
+		expired = append(expired, k)
+	}
+	for _, k := range expired {
+		err := b.Delete(k)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Query returns the readings of the location from since until until, oldest first
+func (s *Store) Query(location string, since, until time.Time) ([]Reading, error) {
+	location = strings.ToLower(strings.TrimSpace(location))
+	ret := []Reading{}
+	err := s.view(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(location))
+		if b == nil {
+			return nil
+		}
+		end := encodeTime(until)
+		c := b.Cursor()
+		for k, v := c.Seek(encodeTime(since)); k != nil && bytes.Compare(k, end) < 0; k, v = c.Next() {
+			r := Reading{}
+			if json.Unmarshal(v, &r) == nil {
+				ret = append(ret, r)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// Locations returns the keys of every location with a reading
+func (s *Store) Locations() []string {
+	ret := []string{}
+	s.view(func(tx *bolt.Tx) error {
+		return tx.ForEach(func(name []byte, b *bolt.Bucket) error {
+			if k, _ := b.Cursor().First(); k != nil {
+				ret = append(ret, string(name))
+			}
+			return nil
+		})
+	})
+	sort.Strings(ret)
+	return ret
+}
+
+// Resolve returns the key of the stored location matching the city, state and country,
+// falling back to a location with just the same city when no location matches exactly
+func (s *Store) Resolve(city, state, country string) string {
+	key := Key(city, state, country)
+	locations := s.Locations()
+	prefix := Key(city, "", "") + ","
+	matches := []string{}
+	for _, l := range locations {
+		if l == key {
+			return key
+		}
+		if strings.HasPrefix(l, prefix) {
+			matches = append(matches, l)
+		}
+	}
+	if len(matches) == 0 {
+		return key
+	}
+	return matches[0]
+}
+
+func (s *Store) view(fn func(*bolt.Tx) error) error {
+	db, err := bolt.Open(s.path, 0644, &bolt.Options{Timeout: LockTimeout, ReadOnly: true})
+	if err != nil {
+		return exception.New(err)
+	}
+	defer db.Close()
+	return exception.New(db.View(fn))
+}
+
+func (s *Store) update(fn func(*bolt.Tx) error) error {
+	db, err := bolt.Open(s.path, 0644, &bolt.Options{Timeout: LockTimeout})
+	if err != nil {
+		return exception.New(err)
+	}
+	defer db.Close()
+	return exception.New(db.Update(fn))
+}
+
+// Import records the readings of a json lines history file, as the store was kept before it was a database
+func (s *Store) Import(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return exception.New(err)
+	}
+	defer f.Close()
+	readings := []Reading{}
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadBytes('\n')
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 {
+			r := Reading{}
+			if json.Unmarshal(line, &r) == nil && len(r.Location) > 0 {
+				readings = append(readings, r)
+			}
+		}
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return exception.New(err)
+		}
+	}
+	return s.update(func(tx *bolt.Tx) error {
+		for _, r := range readings {
+			data, err := json.Marshal(r)
+			if err != nil {
+				return err
+			}
+			b, err := tx.CreateBucketIfNotExists([]byte(r.Location))
+			if err != nil {
+				return err
+			}
+			err = b.Put(encodeTime(r.Time), data)
+			if err != nil {
+				return err
+			}
+			err = s.prune(b)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// encodeTime returns the key of a reading at the time, which sorts in time order, times before 1970 are clamped to it
+func encodeTime(t time.Time) []byte {
+	key := make([]byte, 8)
+	if t.After(time.Unix(0, 0)) {
+		binary.BigEndian.PutUint64(key, uint64(t.UnixNano()))
+	}
+	return key
+}
+
+func decodeTime(key []byte) time.Time {
+	return time.Unix(0, int64(binary.BigEndian.Uint64(key))).UTC()
+}
+
+// Key returns the normalized key of a location
+func Key(city, state, country string) string {
+	parts := []string{}
+	for _, p := range []string{city, state, country} {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if len(p) > 0 {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
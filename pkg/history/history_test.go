@@ -0,0 +1,175 @@
+package history
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2020, time.September, 7, 12, 0, 0, 0, time.UTC)
+
+func tempStore(t *testing.T, retention time.Duration) (*Store, func()) {
+	dir, err := ioutil.TempDir("", "history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := Open(filepath.Join(dir, "history.db"), retention)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	s.now = func() time.Time { return start.Add(24 * time.Hour) }
+	return s, func() { os.RemoveAll(dir) }
+}
+
+func reading(location string, t time.Time, aqi int) *Reading {
+	return &Reading{Location: location, Time: t, AQI: aqi}
+}
+
+func mustRecord(t *testing.T, s *Store, r *Reading, expected bool) {
+	t.Helper()
+	recorded, err := s.Record(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recorded != expected {
+		t.Errorf("recording %s at %s: recorded %v, expected %v", r.Location, r.Time, recorded, expected)
+	}
+}
+
+func TestRecordAndQuery(t *testing.T) {
+	s, done := tempStore(t, 0)
+	defer done()
+	sf := "san francisco, california, usa"
+	mustRecord(t, s, reading(sf, start, 10), true)
+	mustRecord(t, s, reading(sf, start.Add(time.Hour), 20), true)
+	mustRecord(t, s, reading(sf, start.Add(time.Hour), 20), false)
+	mustRecord(t, s, reading(sf, start.Add(30*time.Minute), 15), false)
+	mustRecord(t, s, reading(sf, start.Add(2*time.Hour), 30), true)
+	mustRecord(t, s, reading("oakland, california, usa", start, 40), true)
+	if _, err := s.Record(reading("", start, 0)); err == nil {
+		t.Error("expected an error recording a reading without a location")
+	}
+
+	readings, err := s.Query(" San Francisco, California, USA ", start, start.Add(2*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(readings) != 2 || readings[0].AQI != 10 || readings[1].AQI != 20 || !readings[0].Time.Equal(start) {
+		t.Errorf("expected the readings from the start until before 2 hours oldest first, got %+v", readings)
+	}
+	readings, err = s.Query("nowhere", start, start.Add(time.Hour))
+	if err != nil || len(readings) != 0 {
+		t.Errorf("expected no readings for an unknown location, got %+v %v", readings, err)
+	}
+
+	locations := s.Locations()
+	if len(locations) != 2 || locations[0] != "oakland, california, usa" || locations[1] != sf {
+		t.Errorf("unexpected locations %v", locations)
+	}
+	if key := s.Resolve("San Francisco", "", ""); key != sf {
+		t.Errorf("expected a city to resolve to its stored location, got %q", key)
+	}
+	if key := s.Resolve("Berlin", "Berlin", "Germany"); key != "berlin, berlin, germany" {
+		t.Errorf("expected an unknown location to resolve to its key, got %q", key)
+	}
+}
+
+func TestRecordSharedBetweenStores(t *testing.T) {
+	s, done := tempStore(t, 0)
+	defer done()
+	other, err := Open(s.Path(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustRecord(t, s, reading("sf", start, 10), true)
+	mustRecord(t, other, reading("sf", start, 10), false)
+	mustRecord(t, other, reading("sf", start.Add(time.Hour), 20), true)
+	mustRecord(t, s, reading("sf", start.Add(time.Hour), 20), false)
+	readings, err := s.Query("sf", start, start.Add(2*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(readings) != 2 {
+		t.Errorf("expected each reading recorded once, got %+v", readings)
+	}
+}
+
+func TestRetention(t *testing.T) {
+	s, done := tempStore(t, 12*time.Hour)
+	defer done()
+	mustRecord(t, s, reading("sf", start, 10), true)
+	mustRecord(t, s, reading("sf", start.Add(11*time.Hour), 20), true)
+	mustRecord(t, s, reading("sf", start.Add(13*time.Hour), 30), true)
+	readings, err := s.Query("sf", start, start.Add(24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(readings) != 1 || readings[0].AQI != 30 {
+		t.Errorf("expected only the reading within 12 hours of now to be kept, got %+v", readings)
+	}
+}
+
+func TestOpenImportsJSONLines(t *testing.T) {
+	dir, err := ioutil.TempDir("", "history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "history.jsonl")
+	lines := []byte{}
+	for i, r := range []*Reading{reading("sf", start, 10), reading("sf", start.Add(time.Hour), 20), reading("oakland", start, 30)} {
+		data, err := json.Marshal(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, data...)
+		lines = append(lines, '\n')
+		if i == 0 {
+			lines = append(lines, []byte("not json\n")...)
+		}
+	}
+	err = ioutil.WriteFile(path, lines, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := Open(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	readings, err := s.Query("sf", start, start.Add(2*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(readings) != 2 || readings[1].AQI != 20 {
+		t.Errorf("expected the json lines readings to be imported, got %+v", readings)
+	}
+	if _, err := os.Stat(path + LegacySuffix); err != nil {
+		t.Errorf("expected the json lines file to be kept aside: %v", err)
+	}
+	if _, err := Open(path, 0); err != nil {
+		t.Errorf("expected the imported database to open: %v", err)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	s, done := tempStore(t, 0)
+	defer done()
+	for i, aqi := range []int{100, 100, 40, 60} {
+		mustRecord(t, s, reading("sf", start.Add(time.Duration(i)*time.Hour), aqi), true)
+	}
+	summary, err := s.Summarize("sf", 2*time.Hour, start.Add(4*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Count != 2 || summary.Min != 40 || summary.Max != 60 || summary.Mean != 50 {
+		t.Errorf("unexpected summary of the period %+v", summary)
+	}
+	if summary.Previous != 100 || summary.Trend != TrendImproving {
+		t.Errorf("expected the trend to improve from the prior period, got %v from %v", summary.Trend, summary.Previous)
+	}
+}
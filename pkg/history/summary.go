@@ -0,0 +1,137 @@
+package history
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mat285/aqi/pkg/epa"
+)
+
+const (
+	// MaxReadingDuration is the longest a single reading is counted for when there is a gap before the next
+	MaxReadingDuration = time.Hour
+	// TrendThreshold is how much the mean aqi must change from the prior period to count as a trend
+	TrendThreshold = 5
+)
+
+// Trend is the direction the aqi is moving compared to the prior period
+type Trend string
+
+const (
+	// TrendImproving is a lower mean aqi than the prior period
+	TrendImproving Trend = "improving"
+	// TrendWorsening is a higher mean aqi than the prior period
+	TrendWorsening Trend = "worsening"
+	// TrendSteady is about the same mean aqi as the prior period
+	TrendSteady Trend = "steady"
+	// TrendUnknown is when there are no readings in the prior period to compare to
+	TrendUnknown Trend = "unknown"
+)
+
+// Periods are the supported summary periods by name
+var Periods = map[string]time.Duration{
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+	"30d": 30 * 24 * time.Hour,
+}
+
+// DefaultPeriod is the summary period when none is given
+const DefaultPeriod = "24h"
+
+// ParsePeriod returns the duration of the named period
+func ParsePeriod(name string) (time.Duration, bool) {
+	d, ok := Periods[strings.ToLower(strings.TrimSpace(name))]
+	return d, ok
+}
+
+// CategoryDuration is how long was spent in an epa category
+type CategoryDuration struct {
+	Category epa.Category
+	Duration time.Duration
+}
+
+// Summary summarizes the readings of a location over a period
+type Summary struct {
+	Location   string
+	Since      time.Time
+	Until      time.Time
+	Count      int
+	Min        int
+	Max        int
+	Mean       float64
+	Categories []CategoryDuration
+	Previous   float64
+	Trend      Trend
+}
+
+// Summarize returns the summary of the location's readings for the period ending at until,
+// with the trend compared to the period before it
+func (s *Store) Summarize(location string, period time.Duration, until time.Time) (*Summary, error) {
+	since := until.Add(-period)
+	all, err := s.Query(location, since.Add(-period), until)
+	if err != nil {
+		return nil, err
+	}
+	split := sort.Search(len(all), func(i int) bool { return !all[i].Time.Before(since) })
+	previous, readings := all[:split], all[split:]
+	ret := Summarize(readings, until)
+	ret.Location = location
+	ret.Since = since
+	ret.Until = until
+	ret.Trend = TrendUnknown
+	if len(previous) > 0 && len(readings) > 0 {
+		ret.Previous = Summarize(previous, since).Mean
+		ret.Trend = TrendFor(ret.Previous, ret.Mean)
+	}
+	return ret, nil
+}
+
+// Summarize returns the stats and time spent in each category of the readings, which must be oldest first,
+// each reading counts until the next one or until, at most MaxReadingDuration
+func Summarize(readings []Reading, until time.Time) *Summary {
+	ret := &Summary{Count: len(readings)}
+	durations := make([]time.Duration, len(epa.Categories))
+	total := 0
+	for i, r := range readings {
+		if i == 0 || r.AQI < ret.Min {
+			ret.Min = r.AQI
+		}
+		if i == 0 || r.AQI > ret.Max {
+			ret.Max = r.AQI
+		}
+		total += r.AQI
+
+		end := until
+		if i+1 < len(readings) {
+			end = readings[i+1].Time
+		}
+		d := end.Sub(r.Time)
+		if d > MaxReadingDuration {
+			d = MaxReadingDuration
+		}
+		if d > 0 {
+			durations[epa.CategoryForAQI(r.AQI).Severity()] += d
+		}
+	}
+	if len(readings) > 0 {
+		ret.Mean = float64(total) / float64(len(readings))
+	}
+	for i, c := range epa.Categories {
+		if durations[i] > 0 {
+			ret.Categories = append(ret.Categories, CategoryDuration{Category: c, Duration: durations[i]})
+		}
+	}
+	return ret
+}
+
+// TrendFor returns the trend from the previous mean aqi to the current one
+func TrendFor(previous, current float64) Trend {
+	switch {
+	case current-previous >= TrendThreshold:
+		return TrendWorsening
+	case previous-current >= TrendThreshold:
+		return TrendImproving
+	}
+	return TrendSteady
+}
@@ -0,0 +1,166 @@
+package index
+
+import (
+	"strings"
+
+	"github.com/mat285/aqi/pkg/airvisual"
+	"github.com/mat285/aqi/pkg/epa"
+)
+
+// Index is an air quality index
+type Index string
+
+const (
+	// US is the us epa aqi
+	US Index = "us"
+	// CN is the china aqi
+	CN Index = "cn"
+	// CAQI is the european common air quality index
+	CAQI Index = "caqi"
+	// DAQI is the uk daily air quality index
+	DAQI Index = "daqi"
+	// NAQI is the india national air quality index
+	NAQI Index = "naqi"
+	// Local is not an index, it selects the local index of each location
+	Local Index = "local"
+)
+
+var (
+	// Indexes are the supported indexes
+	Indexes = []Index{US, CN, CAQI, DAQI, NAQI}
+
+	// countryIndexes are the local indexes of countries as airvisual names them
+	countryIndexes = map[string]Index{
+		"china":          CN,
+		"india":          NAQI,
+		"uk":             DAQI,
+		"united kingdom": DAQI,
+		"austria":        CAQI,
+		"belgium":        CAQI,
+		"bulgaria":       CAQI,
+		"croatia":        CAQI,
+		"czech republic": CAQI,
+		"denmark":        CAQI,
+		"estonia":        CAQI,
+		"finland":        CAQI,
+		"france":         CAQI,
+		"germany":        CAQI,
+		"greece":         CAQI,
+		"hungary":        CAQI,
+		"ireland":        CAQI,
+		"italy":          CAQI,
+		"latvia":         CAQI,
+		"lithuania":      CAQI,
+		"luxembourg":     CAQI,
+		"netherlands":    CAQI,
+		"poland":         CAQI,
+		"portugal":       CAQI,
+		"romania":        CAQI,
+		"slovakia":       CAQI,
+		"slovenia":       CAQI,
+		"spain":          CAQI,
+		"sweden":         CAQI,
+	}
+)
+
+// Parse returns the index for the name
+func Parse(name string) (Index, bool) {
+	i := Index(strings.ToLower(strings.TrimSpace(name)))
+	if i == Local {
+		return i, true
+	}
+	for _, known := range Indexes {
+		if i == known {
+			return i, true
+		}
+	}
+	return "", false
+}
+
+// ForCountry returns the local index of the country, defaulting to the us aqi
+func ForCountry(country string) Index {
+	if i, ok := countryIndexes[strings.ToLower(strings.TrimSpace(country))]; ok {
+		return i
+	}
+	return US
+}
+
+// Resolve returns the index to use for the country, resolving local to the country's index
+func (i Index) Resolve(country string) Index {
+	if i == Local {
+		return ForCountry(country)
+	}
+	if len(i) == 0 {
+		return US
+	}
+	return i
+}
+
+// Name returns the display name of the index
+func (i Index) Name() string {
+	switch i {
+	case US:
+		return "AQI"
+	case CN:
+		return "China AQI"
+	case CAQI:
+		return "CAQI"
+	case DAQI:
+		return "DAQI"
+	case NAQI:
+		return "India AQI"
+	}
+	return string(i)
+}
+
+// Reading is a value on an index
+type Reading struct {
+	Index     Index
+	Value     int
+	Pollutant airvisual.Pollutant
+	Band      Band
+}
+
+// Compute returns the reading on the index for the pollution, and false if there is no data to compute it from.
+// The us and china indexes use the values airvisual reports when present, otherwise all indexes are computed
+// from the pollutant concentrations, taking the worst pollutant.
+func Compute(i Index, p airvisual.Pollution) (*Reading, bool) {
+	switch i {
+	case US, "":
+		if p.AQI <= 0 && p.MainPollutant == "" {
+			normalized := p
+			if !epa.Normalize(&normalized) {
+				return nil, false
+			}
+			p = normalized
+		}
+		return &Reading{Index: US, Value: p.AQI, Pollutant: p.MainPollutant, Band: US.Band(p.AQI)}, true
+	case CN:
+		if p.AQICN > 0 || p.MainPollutantCN != "" {
+			return &Reading{Index: CN, Value: p.AQICN, Pollutant: p.MainPollutantCN, Band: CN.Band(p.AQICN)}, true
+		}
+	}
+	s, ok := scales[i]
+	if !ok {
+		return nil, false
+	}
+	var ret *Reading
+	for _, pollutant := range epa.Pollutants {
+		conc := p.Concentration(pollutant)
+		if conc == nil {
+			continue
+		}
+		value, ok := s.value(pollutant, airvisual.MicrogramsPerCubicMeter(pollutant, conc.Value))
+		if !ok {
+			continue
+		}
+		if ret == nil || value > ret.Value {
+			ret = &Reading{Index: i, Value: value, Pollutant: pollutant}
+		}
+	}
+	if ret == nil {
+		return nil, false
+	}
+	ret.Band = i.Band(ret.Value)
+	return ret, true
+}
@@ -0,0 +1,163 @@
+package index
+
+import (
+	"math"
+
+	"github.com/mat285/aqi/pkg/airvisual"
+	"github.com/mat285/aqi/pkg/epa"
+)
+
+// Band is a labeled range of an index
+type Band struct {
+	// Max is the highest value in the band
+	Max   int
+	Label string
+	// Severity ranks the band from 0 for good through 5 for hazardous so bands compare across indexes
+	Severity int
+}
+
+var (
+	bands = map[Index][]Band{
+		US: usBands(),
+		CN: {
+			{50, "Excellent", 0},
+			{100, "Good", 1},
+			{150, "Lightly Polluted", 2},
+			{200, "Moderately Polluted", 3},
+			{300, "Heavily Polluted", 4},
+			{math.MaxInt32, "Severely Polluted", 5},
+		},
+		CAQI: {
+			{25, "Very Low", 0},
+			{50, "Low", 1},
+			{75, "Medium", 2},
+			{100, "High", 3},
+			{math.MaxInt32, "Very High", 4},
+		},
+		DAQI: {
+			{3, "Low", 0},
+			{6, "Moderate", 1},
+			{9, "High", 2},
+			{math.MaxInt32, "Very High", 3},
+		},
+		NAQI: {
+			{50, "Good", 0},
+			{100, "Satisfactory", 1},
+			{200, "Moderate", 2},
+			{300, "Poor", 3},
+			{400, "Very Poor", 4},
+			{math.MaxInt32, "Severe", 5},
+		},
+	}
+
+	// scales convert concentrations in ug/m3 to each index, co is in ug/m3 as well
+	scales = map[Index]scale{
+		CN: {
+			round: math.Ceil,
+			max:   500,
+			index: []float64{0, 50, 100, 150, 200, 300, 400, 500},
+			conc: map[airvisual.Pollutant][]float64{
+				airvisual.PollutantPM25: {0, 35, 75, 115, 150, 250, 350, 500},
+				airvisual.PollutantPM10: {0, 50, 150, 250, 350, 420, 500, 600},
+				airvisual.PollutantO3:   {0, 160, 200, 300, 400, 800, 1000, 1200},
+				airvisual.PollutantNO2:  {0, 40, 80, 180, 280, 565, 750, 940},
+				airvisual.PollutantSO2:  {0, 50, 150, 475, 800, 1600, 2100, 2620},
+				airvisual.PollutantCO:   {0, 2000, 4000, 14000, 24000, 36000, 48000, 60000},
+			},
+		},
+		// caqi values above 100 are extrapolated from the top grid band
+		CAQI: {
+			round: math.Round,
+			index: []float64{0, 25, 50, 75, 100},
+			conc: map[airvisual.Pollutant][]float64{
+				airvisual.PollutantPM25: {0, 15, 30, 55, 110},
+				airvisual.PollutantPM10: {0, 25, 50, 90, 180},
+				airvisual.PollutantO3:   {0, 60, 120, 180, 240},
+				airvisual.PollutantNO2:  {0, 50, 100, 200, 400},
+				airvisual.PollutantSO2:  {0, 50, 100, 350, 500},
+				airvisual.PollutantCO:   {0, 5000, 7500, 10000, 20000},
+			},
+		},
+		// daqi is banded, the concentrations are the upper bounds of bands 1 through 9
+		DAQI: {
+			banded: true,
+			conc: map[airvisual.Pollutant][]float64{
+				airvisual.PollutantPM25: {11, 23, 35, 41, 47, 53, 58, 64, 70},
+				airvisual.PollutantPM10: {16, 33, 50, 58, 66, 75, 83, 91, 100},
+				airvisual.PollutantO3:   {33, 66, 100, 120, 140, 160, 187, 213, 240},
+				airvisual.PollutantNO2:  {67, 134, 200, 267, 334, 400, 467, 534, 600},
+				airvisual.PollutantSO2:  {88, 177, 266, 354, 443, 532, 710, 887, 1064},
+			},
+		},
+		// naqi has no upper concentration for severe, so it is extended by the width of the band below
+		NAQI: {
+			round: math.Round,
+			max:   500,
+			index: []float64{0, 50, 100, 200, 300, 400, 500},
+			conc: map[airvisual.Pollutant][]float64{
+				airvisual.PollutantPM25: {0, 30, 60, 90, 120, 250, 380},
+				airvisual.PollutantPM10: {0, 50, 100, 250, 350, 430, 510},
+				airvisual.PollutantO3:   {0, 50, 100, 168, 208, 748, 1288},
+				airvisual.PollutantNO2:  {0, 40, 80, 180, 280, 400, 520},
+				airvisual.PollutantSO2:  {0, 40, 80, 380, 800, 1600, 2400},
+				airvisual.PollutantCO:   {0, 1000, 2000, 10000, 17000, 34000, 51000},
+			},
+		},
+	}
+)
+
+// usBands returns the bands of the epa categories
+func usBands() []Band {
+	ret := make([]Band, 0, len(epa.Categories))
+	for _, c := range epa.Categories {
+		ret = append(ret, Band{Max: c.Max, Label: c.Name, Severity: c.Severity()})
+	}
+	return ret
+}
+
+// Band returns the band the value falls in on the index
+func (i Index) Band(value int) Band {
+	b, ok := bands[i]
+	if !ok {
+		b = bands[US]
+	}
+	for _, band := range b {
+		if value <= band.Max {
+			return band
+		}
+	}
+	return b[len(b)-1]
+}
+
+// scale is a piecewise linear, or banded, mapping of concentrations to an index
+type scale struct {
+	banded bool
+	round  func(float64) float64
+	max    float64
+	index  []float64
+	conc   map[airvisual.Pollutant][]float64
+}
+
+func (s scale) value(p airvisual.Pollutant, c float64) (int, bool) {
+	bps, ok := s.conc[p]
+	if !ok || math.IsNaN(c) || c < 0 {
+		return 0, false
+	}
+	if s.banded {
+		c = math.Round(c)
+		for i, upper := range bps {
+			if c <= upper {
+				return i + 1, true
+			}
+		}
+		return len(bps) + 1, true
+	}
+	i := 1
+	for ; i < len(bps)-1 && c > bps[i]; i++ {
+	}
+	v := s.index[i-1] + (s.index[i]-s.index[i-1])/(bps[i]-bps[i-1])*(c-bps[i-1])
+	if s.max > 0 && v > s.max {
+		v = s.max
+	}
+	return int(s.round(v)), true
+}
@@ -0,0 +1,127 @@
+package index
+
+import (
+	"math"
+	"testing"
+
+	"github.com/mat285/aqi/pkg/airvisual"
+)
+
+func TestScaleValue(t *testing.T) {
+	cases := []struct {
+		index     Index
+		pollutant airvisual.Pollutant
+		conc      float64
+		expected  int
+	}{
+		{CN, airvisual.PollutantPM25, 0, 0},
+		{CN, airvisual.PollutantPM25, 35, 50},
+		{CN, airvisual.PollutantPM25, 36, 52},
+		{CN, airvisual.PollutantPM25, 75, 100},
+		{CN, airvisual.PollutantPM25, 500, 500},
+		{CN, airvisual.PollutantPM25, 600, 500},
+		{CN, airvisual.PollutantCO, 4000, 100},
+
+		{CAQI, airvisual.PollutantPM25, 15, 25},
+		{CAQI, airvisual.PollutantPM25, 22.5, 38},
+		{CAQI, airvisual.PollutantPM25, 30, 50},
+		{CAQI, airvisual.PollutantPM25, 110, 100},
+		{CAQI, airvisual.PollutantPM25, 220, 150},
+		{CAQI, airvisual.PollutantNO2, 400, 100},
+
+		{DAQI, airvisual.PollutantPM25, 0, 1},
+		{DAQI, airvisual.PollutantPM25, 11, 1},
+		{DAQI, airvisual.PollutantPM25, 11.4, 1},
+		{DAQI, airvisual.PollutantPM25, 11.5, 2},
+		{DAQI, airvisual.PollutantPM25, 35, 3},
+		{DAQI, airvisual.PollutantPM25, 36, 4},
+		{DAQI, airvisual.PollutantPM25, 70, 9},
+		{DAQI, airvisual.PollutantPM25, 71, 10},
+		{DAQI, airvisual.PollutantO3, 101, 4},
+
+		{NAQI, airvisual.PollutantPM25, 30, 50},
+		{NAQI, airvisual.PollutantPM25, 45, 75},
+		{NAQI, airvisual.PollutantPM25, 60, 100},
+		{NAQI, airvisual.PollutantPM25, 90, 200},
+		{NAQI, airvisual.PollutantPM25, 250, 400},
+		{NAQI, airvisual.PollutantPM25, 380, 500},
+		{NAQI, airvisual.PollutantPM25, 500, 500},
+		{NAQI, airvisual.PollutantSO2, 80, 100},
+	}
+	for _, c := range cases {
+		value, ok := scales[c.index].value(c.pollutant, c.conc)
+		if !ok || value != c.expected {
+			t.Errorf("%s %s at %v: got %d %v, expected %d", c.index, c.pollutant, c.conc, value, ok, c.expected)
+		}
+	}
+
+	for _, c := range []struct {
+		index     Index
+		pollutant airvisual.Pollutant
+		conc      float64
+	}{
+		{DAQI, airvisual.PollutantCO, 1000},
+		{CN, airvisual.PollutantPM25, -1},
+		{NAQI, airvisual.PollutantPM25, math.NaN()},
+	} {
+		if value, ok := scales[c.index].value(c.pollutant, c.conc); ok {
+			t.Errorf("%s %s at %v: expected no value, got %d", c.index, c.pollutant, c.conc, value)
+		}
+	}
+}
+
+func TestBand(t *testing.T) {
+	cases := []struct {
+		index    Index
+		value    int
+		label    string
+		severity int
+	}{
+		{CN, 50, "Excellent", 0},
+		{CN, 151, "Moderately Polluted", 3},
+		{CN, 301, "Severely Polluted", 5},
+		{CAQI, 25, "Very Low", 0},
+		{CAQI, 26, "Low", 1},
+		{CAQI, 101, "Very High", 4},
+		{DAQI, 1, "Low", 0},
+		{DAQI, 4, "Moderate", 1},
+		{DAQI, 7, "High", 2},
+		{DAQI, 10, "Very High", 3},
+		{NAQI, 100, "Satisfactory", 1},
+		{NAQI, 401, "Severe", 5},
+		{US, 151, "Unhealthy", 3},
+		{Index("unknown"), 151, "Unhealthy", 3},
+	}
+	for _, c := range cases {
+		if band := c.index.Band(c.value); band.Label != c.label || band.Severity != c.severity {
+			t.Errorf("%s %d: band %+v, expected %s with severity %d", c.index, c.value, band, c.label, c.severity)
+		}
+	}
+
+	for i, band := range bands[DAQI] {
+		if band.Severity != i {
+			t.Errorf("expected the daqi bands to rank one apart, %s is %d", band.Label, band.Severity)
+		}
+	}
+}
+
+func TestCompute(t *testing.T) {
+	p := airvisual.Pollution{
+		PM25: &airvisual.Concentration{Value: 42},
+		PM10: &airvisual.Concentration{Value: 20},
+	}
+	r, ok := Compute(DAQI, p)
+	if !ok || r.Value != 5 || r.Pollutant != airvisual.PollutantPM25 || r.Band.Label != "Moderate" {
+		t.Errorf("expected the worst pollutant's daqi, got %+v", r)
+	}
+
+	p.AQICN, p.MainPollutantCN = 120, airvisual.PollutantPM10
+	r, ok = Compute(CN, p)
+	if !ok || r.Value != 120 || r.Pollutant != airvisual.PollutantPM10 {
+		t.Errorf("expected the reported china aqi, got %+v", r)
+	}
+
+	if r, ok := Compute(CAQI, airvisual.Pollution{}); ok {
+		t.Errorf("expected no reading without concentrations, got %+v", r)
+	}
+}
@@ -0,0 +1,66 @@
+package openaq
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+
+	exception "github.com/blend/go-sdk/exception"
+	request "github.com/blend/go-sdk/request"
+)
+
+// Client is an openaq client
+type Client struct {
+	apiKey string
+}
+
+// New returns a new openaq client
+func New(apiKey string) *Client {
+	return &Client{
+		apiKey: apiKey,
+	}
+}
+
+// Nearest returns the locations within the default radius of the coordinates, nearest first
+func (c *Client) Nearest(lat, lon float64) ([]Location, error) {
+	v := url.Values{}
+	v.Set("coordinates", fmt.Sprintf("%v,%v", lat, lon))
+	v.Set("radius", strconv.Itoa(DefaultRadius))
+	v.Set("limit", strconv.Itoa(DefaultLimit))
+	resp := &LocationsResponse{}
+	err := c.get(LocationsURL, v, resp)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(resp.Results, func(i, j int) bool {
+		return resp.Results[i].Distance < resp.Results[j].Distance
+	})
+	return resp.Results, nil
+}
+
+// Latest returns the latest measurement of each sensor of the location
+func (c *Client) Latest(locationID int) ([]Latest, error) {
+	resp := &LatestResponse{}
+	err := c.get(fmt.Sprintf(LatestURLFormat, locationID), url.Values{}, resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Results, nil
+}
+
+func (c *Client) get(base string, v url.Values, resp interface{}) error {
+	if len(c.apiKey) == 0 {
+		return exception.New("MissingAPIKey")
+	}
+	u, _ := url.Parse(base)
+	u.RawQuery = v.Encode()
+	meta, err := request.Get(u.String()).WithHeader(APIKeyHeader, c.apiKey).JSONWithMeta(resp)
+	if meta != nil && meta.StatusCode != http.StatusOK {
+		return exception.New("RequestFailed").WithMessagef("status code %d", meta.StatusCode)
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package openaq
+
+import "time"
+
+const (
+	// BaseURL is the base url for requests
+	BaseURL = "https://api.openaq.org/v3/"
+	// LocationsURL is the url for location requests
+	LocationsURL = BaseURL + "locations"
+	// LatestURLFormat is the url for the latest measurements of a location, formatted with its id
+	LatestURLFormat = LocationsURL + "/%d/latest"
+
+	// APIKeyHeader is the header the api key is sent in
+	APIKeyHeader = "X-API-Key"
+
+	// DefaultRadius is the default radius in meters to search for locations, the most the api allows
+	DefaultRadius = 25000
+	// DefaultLimit is the default number of locations to search for
+	DefaultLimit = 10
+)
+
+const (
+	// ParameterPM25 is the parameter for pm2.5
+	ParameterPM25 = "pm25"
+	// ParameterPM10 is the parameter for pm10
+	ParameterPM10 = "pm10"
+	// ParameterO3 is the parameter for ozone
+	ParameterO3 = "o3"
+	// ParameterNO2 is the parameter for nitrogen dioxide
+	ParameterNO2 = "no2"
+	// ParameterSO2 is the parameter for sulfur dioxide
+	ParameterSO2 = "so2"
+	// ParameterCO is the parameter for carbon monoxide
+	ParameterCO = "co"
+)
+
+const (
+	// UnitMicrogramsPerCubicMeter is micrograms per cubic meter
+	UnitMicrogramsPerCubicMeter = "µg/m³"
+	// UnitPPM is parts per million
+	UnitPPM = "ppm"
+	// UnitPPB is parts per billion
+	UnitPPB = "ppb"
+)
+
+// LocationsResponse is a response for locations
+type LocationsResponse struct {
+	Results []Location `json:"results"`
+}
+
+// Location is a location with sensors
+type Location struct {
+	ID            int         `json:"id"`
+	Name          string      `json:"name"`
+	Locality      string      `json:"locality"`
+	Timezone      string      `json:"timezone"`
+	Country       Country     `json:"country"`
+	Coordinates   Coordinates `json:"coordinates"`
+	Sensors       []Sensor    `json:"sensors"`
+	Distance      float64     `json:"distance"`
+	DatetimeFirst *Datetime   `json:"datetimeFirst"`
+	DatetimeLast  *Datetime   `json:"datetimeLast"`
+}
+
+// Sensor returns the location's sensor with the id
+func (l Location) Sensor(id int) (Sensor, bool) {
+	for _, s := range l.Sensors {
+		if s.ID == id {
+			return s, true
+		}
+	}
+	return Sensor{}, false
+}
+
+// Country is the country of a location
+type Country struct {
+	ID   int    `json:"id"`
+	Code string `json:"code"`
+	Name string `json:"name"`
+}
+
+// Sensor is a sensor of a location measuring a single parameter
+type Sensor struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Parameter Parameter `json:"parameter"`
+}
+
+// Parameter is a measured parameter and its units
+type Parameter struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Units       string `json:"units"`
+	DisplayName string `json:"displayName"`
+}
+
+// Coordinates are the coordinates of a location
+type Coordinates struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// Datetime is a time in utc and in the location's timezone
+type Datetime struct {
+	UTC   time.Time `json:"utc"`
+	Local string    `json:"local"`
+}
+
+// LatestResponse is a response for the latest measurements of a location
+type LatestResponse struct {
+	Results []Latest `json:"results"`
+}
+
+// Latest is the latest measurement of a sensor
+type Latest struct {
+	Datetime    Datetime    `json:"datetime"`
+	Value       float64     `json:"value"`
+	Coordinates Coordinates `json:"coordinates"`
+	SensorsID   int         `json:"sensorsId"`
+	LocationsID int         `json:"locationsId"`
+}
@@ -0,0 +1,59 @@
+package openaq
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func readFixture(t *testing.T, name string, v interface{}) {
+	t.Helper()
+	data, err := ioutil.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLocationsResponse(t *testing.T) {
+	resp := &LocationsResponse{}
+	readFixture(t, "locations.json", resp)
+	if len(resp.Results) != 2 {
+		t.Fatalf("expected 2 locations, got %d", len(resp.Results))
+	}
+	l := resp.Results[0]
+	if l.ID != 8881 || l.Country.Code != "US" || l.Coordinates.Latitude != 37.7658 || l.Coordinates.Longitude != -122.3978 || l.Distance != 1520.4 {
+		t.Errorf("unexpected location %+v", l)
+	}
+	if l.DatetimeLast == nil || !l.DatetimeLast.UTC.Equal(time.Date(2020, time.September, 7, 19, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected last measurement time %+v", l.DatetimeLast)
+	}
+	sensor, ok := l.Sensor(23302)
+	if !ok || sensor.Parameter.Name != ParameterO3 || sensor.Parameter.Units != UnitPPM {
+		t.Errorf("unexpected sensor %+v", sensor)
+	}
+	if sensor, ok := l.Sensor(23301); !ok || sensor.Parameter.Units != UnitMicrogramsPerCubicMeter {
+		t.Errorf("unexpected sensor %+v", sensor)
+	}
+	if _, ok := l.Sensor(3901); ok {
+		t.Error("expected no sensor of another location")
+	}
+	if len(resp.Results[1].Locality) != 0 {
+		t.Errorf("expected a null locality to be empty, got %q", resp.Results[1].Locality)
+	}
+}
+
+func TestLatestResponse(t *testing.T) {
+	resp := &LatestResponse{}
+	readFixture(t, "latest.json", resp)
+	if len(resp.Results) != 5 {
+		t.Fatalf("expected 5 measurements, got %d", len(resp.Results))
+	}
+	m := resp.Results[0]
+	if m.SensorsID != 23301 || m.LocationsID != 8881 || m.Value != 35.5 || !m.Datetime.UTC.Equal(time.Date(2020, time.September, 7, 19, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected measurement %+v", m)
+	}
+}
@@ -0,0 +1,84 @@
+package prefs
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	exception "github.com/blend/go-sdk/exception"
+	"github.com/mat285/aqi/pkg/fileutil"
+)
+
+// Preferences are a slack user's preferences, unset fields use the config's defaults
+type Preferences struct {
+	// Location is the location looked up when none is given, in the same format as the slash command
+	Location string `json:"location,omitempty"`
+	// Index is the index to report in
+	Index string `json:"index,omitempty"`
+	// Units are the units to show the weather in, `c` or `f`
+	Units string `json:"units,omitempty"`
+	// Private makes replies only visible to the user
+	Private bool `json:"private,omitempty"`
+}
+
+// IsZero returns if no preferences are set
+func (p Preferences) IsZero() bool {
+	return p == Preferences{}
+}
+
+// Store persists the preferences of slack users to a json file
+type Store struct {
+	lock  sync.Mutex
+	path  string
+	Users map[string]Preferences `json:"users"`
+}
+
+// Load loads the store from the file, a missing file is an empty store
+func Load(path string) (*Store, error) {
+	s := &Store{path: path, Users: map[string]Preferences{}}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	} else if err != nil {
+		return nil, exception.New(err)
+	}
+	err = json.Unmarshal(data, s)
+	if err != nil {
+		return nil, exception.New(err)
+	}
+	if s.Users == nil {
+		s.Users = map[string]Preferences{}
+	}
+	return s, nil
+}
+
+// Get returns the preferences of the user, none are set if the user has not set any
+func (s *Store) Get(user string) Preferences {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.Users[user]
+}
+
+// Update changes the preferences of the user and saves the store, returning the new preferences
+func (s *Store) Update(user string, update func(*Preferences)) (Preferences, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	p := s.Users[user]
+	update(&p)
+	if p.IsZero() {
+		delete(s.Users, user)
+	} else {
+		s.Users[user] = p
+	}
+	return p, s.save()
+}
+
+// save writes the store to its file, replacing it atomically
+func (s *Store) save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return exception.New(err)
+	}
+	return fileutil.WriteAtomic(s.path, data)
+}
@@ -0,0 +1,71 @@
+package prefs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUpdate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "prefs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "preferences.json")
+	s, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := s.Get("U1"); !p.IsZero() {
+		t.Errorf("expected no preferences for a new user, got %+v", p)
+	}
+
+	p, err := s.Update("U1", func(p *Preferences) {
+		p.Location = "sf"
+		p.Units = "c"
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Location != "sf" || p.Units != "c" {
+		t.Errorf("expected the updated preferences, got %+v", p)
+	}
+	p, err = s.Update("U1", func(p *Preferences) { p.Private = true })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Location != "sf" || !p.Private {
+		t.Errorf("expected an update to keep the other preferences, got %+v", p)
+	}
+	if _, err := s.Update("U2", func(p *Preferences) { p.Index = "daqi" }); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := loaded.Get("U1"); p != (Preferences{Location: "sf", Units: "c", Private: true}) {
+		t.Errorf("expected the preferences to be saved, got %+v", p)
+	}
+
+	p, err = s.Update("U1", func(p *Preferences) { *p = Preferences{} })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.IsZero() {
+		t.Errorf("expected the preferences to be cleared, got %+v", p)
+	}
+	if _, ok := s.Users["U1"]; ok {
+		t.Error("expected clearing every preference to delete the user's entry")
+	}
+	loaded, err = Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := loaded.Users["U1"]; ok || len(loaded.Users) != 1 || loaded.Get("U2").Index != "daqi" {
+		t.Errorf("expected only the other user's preferences to be saved, got %+v", loaded.Users)
+	}
+}
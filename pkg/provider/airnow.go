@@ -0,0 +1,68 @@
+package provider
+
+import (
+	exception "github.com/blend/go-sdk/exception"
+	"github.com/mat285/aqi/pkg/airnow"
+	"github.com/mat285/aqi/pkg/airvisual"
+)
+
+// AirNow provides air data from the epa's airnow, which only covers the us by coordinates
+type AirNow struct {
+	Client *airnow.Client
+}
+
+// NewAirNow returns a new airnow provider
+func NewAirNow(apiKey string) *AirNow {
+	return &AirNow{Client: airnow.New(apiKey)}
+}
+
+// Name returns the name of the provider
+func (a *AirNow) Name() string {
+	return "airnow"
+}
+
+// Current returns the current air data for the location
+func (a *AirNow) Current(l *Location) (*airvisual.Data, error) {
+	if !l.HasCoordinates() {
+		return nil, exception.New(ErrRequiresCoordinates).WithMessage(a.Name())
+	}
+	obs, err := a.Client.LatLong(l.Coordinates.Latitude, l.Coordinates.Longitude)
+	if err != nil {
+		return nil, err
+	}
+	if len(obs) == 0 {
+		return nil, exception.New(ErrNoData).WithMessagef("%s has no data for %s", a.Name(), l)
+	}
+	return airNowData(obs)
+}
+
+// airNowData returns the data for the observations, the aqi is the worst of the parameters
+func airNowData(obs []airnow.Observation) (*airvisual.Data, error) {
+	first := obs[0]
+	data := &airvisual.Data{
+		City:    first.ReportingArea,
+		State:   first.StateCode,
+		Country: "USA",
+		Location: airvisual.Location{
+			Type:        "Point",
+			Coordinates: []float64{first.Longitude, first.Latitude},
+		},
+	}
+	data.Current.Pollution.AQI = -1
+	for _, o := range obs {
+		pollutant := pollutantFromCode(o.ParameterName)
+		if len(pollutant) == 0 {
+			continue
+		}
+		if o.AQI > data.Current.Pollution.AQI {
+			data.Current.Pollution.AQI = o.AQI
+			data.Current.Pollution.MainPollutant = pollutant
+			t, err := o.Time()
+			if err != nil {
+				return nil, err
+			}
+			data.Current.Pollution.Time = t
+		}
+	}
+	return data, nil
+}
@@ -0,0 +1,62 @@
+package provider
+
+import (
+	"time"
+
+	exception "github.com/blend/go-sdk/exception"
+	logger "github.com/blend/go-sdk/logger"
+	"github.com/mat285/aqi/pkg/airvisual"
+	"github.com/mat285/aqi/pkg/config"
+	"github.com/mat285/aqi/pkg/epa"
+	"github.com/mat285/aqi/pkg/quota"
+)
+
+// AirVisual provides air data from airvisual
+type AirVisual struct {
+	Client *airvisual.Client
+}
+
+// NewAirVisual returns a new airvisual provider
+func NewAirVisual(apiKey string) *AirVisual {
+	return &AirVisual{Client: airvisual.New(apiKey)}
+}
+
+// AirVisualClient returns the airvisual client for the config, with every call counted against the
+// configured budgets by a limiter shared across the process
+func AirVisualClient(c *config.Config, log *logger.Logger) (*airvisual.Client, error) {
+	l, err := quota.Shared(c.GetAirVisualQuotaFile(), c.AirVisualRateLimit, c.AirVisualMonthlyQuota, log)
+	if err != nil {
+		return nil, err
+	}
+	return airvisual.New(c.AirVisualAPIKey).WithLimiter(l), nil
+}
+
+// Name returns the name of the provider
+func (a *AirVisual) Name() string {
+	return "airvisual"
+}
+
+// Current returns the current air data for the location
+func (a *AirVisual) Current(l *Location) (*airvisual.Data, error) {
+	var resp *airvisual.Response
+	var err error
+	if l.HasCity() {
+		resp, err = a.Client.Location(l.LocationRequest())
+	} else if l.HasCoordinates() {
+		resp, err = a.Client.NearestCity(l.Coordinates.Latitude, l.Coordinates.Longitude)
+	} else {
+		return nil, exception.New("MissingLocation")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if resp.Status != airvisual.StatusSuccess {
+		return nil, exception.New("RequestFailed").WithMessagef("%v", resp)
+	}
+	data := &resp.Data
+	if p := data.Current.Pollution; p.AQI <= 0 && p.MainPollutant == "" {
+		// plans that only return concentrations are normalized locally
+		epa.NormalizeData(data, time.Now().UTC())
+	}
+	return data, nil
+}
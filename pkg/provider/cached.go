@@ -0,0 +1,50 @@
+package provider
+
+import (
+	"time"
+
+	logger "github.com/blend/go-sdk/logger"
+	"github.com/mat285/aqi/pkg/airvisual"
+	"github.com/mat285/aqi/pkg/cache"
+)
+
+// Cached serves air data from the cache while it is fresh and otherwise from the provider, caching what it returns
+type Cached struct {
+	Provider Provider
+	Cache    *cache.Cache
+	Log      *logger.Logger
+}
+
+// Name returns the name of the provider
+func (c *Cached) Name() string {
+	return c.Provider.Name()
+}
+
+// Current returns the current air data for the location, with when it was fetched set
+func (c *Cached) Current(l *Location) (*airvisual.Data, error) {
+	key := l.Key()
+	now := time.Now().UTC()
+	if e, ok := c.Cache.Get(key, now); ok {
+		if c.Log != nil {
+			c.Log.SyncInfof("Using cached air data for `%s` fetched `%s` ago", key, e.Age(now).Round(time.Second))
+		}
+		return withFetched(e), nil
+	}
+	data, err := c.Provider.Current(l)
+	if err != nil {
+		return nil, err
+	}
+	err = c.Cache.Set(key, data, now)
+	if err != nil && c.Log != nil {
+		c.Log.SyncError(err)
+	}
+	data.Fetched = now
+	return data, nil
+}
+
+// withFetched returns a copy of the entry's data with when it was fetched set
+func withFetched(e *cache.Entry) *airvisual.Data {
+	data := *e.Data
+	data.Fetched = e.Fetched
+	return &data
+}
@@ -0,0 +1,132 @@
+package provider
+
+import (
+	"time"
+
+	exception "github.com/blend/go-sdk/exception"
+	"github.com/mat285/aqi/pkg/airvisual"
+	"github.com/mat285/aqi/pkg/epa"
+	"github.com/mat285/aqi/pkg/openaq"
+)
+
+// OpenAQStaleAfter is how long since a location's last measurement it is skipped for a nearer one
+// that is still reporting, many openaq locations are no longer active
+const OpenAQStaleAfter = 3 * time.Hour
+
+// OpenAQ provides air data from openaq, which reports raw concentrations that are converted to the epa aqi.
+// The openaq api can only search for locations by coordinates
+type OpenAQ struct {
+	Client *openaq.Client
+}
+
+// NewOpenAQ returns a new openaq provider
+func NewOpenAQ(apiKey string) *OpenAQ {
+	return &OpenAQ{Client: openaq.New(apiKey)}
+}
+
+// Name returns the name of the provider
+func (o *OpenAQ) Name() string {
+	return "openaq"
+}
+
+// Current returns the current air data for the location
+func (o *OpenAQ) Current(l *Location) (*airvisual.Data, error) {
+	if !l.HasCoordinates() {
+		return nil, exception.New(ErrRequiresCoordinates).WithMessage(o.Name())
+	}
+	locations, err := o.Client.Nearest(l.Coordinates.Latitude, l.Coordinates.Longitude)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	location, ok := nearestReporting(locations, now)
+	if !ok {
+		return nil, exception.New(ErrNoData).WithMessagef("%s has no data for %s", o.Name(), l)
+	}
+	latest, err := o.Client.Latest(location.ID)
+	if err != nil {
+		return nil, err
+	}
+	data := openAQData(location, latest, l)
+	if !epa.NormalizeData(data, now) {
+		return nil, exception.New(ErrNoAQI).WithMessagef("%s has no aqi for %s", o.Name(), l)
+	}
+	return data, nil
+}
+
+// nearestReporting returns the nearest of the locations, which are nearest first, with a measurement
+// within OpenAQStaleAfter of now
+func nearestReporting(locations []openaq.Location, now time.Time) (openaq.Location, bool) {
+	for _, location := range locations {
+		if location.DatetimeLast != nil && now.Sub(location.DatetimeLast.UTC) <= OpenAQStaleAfter {
+			return location, true
+		}
+	}
+	return openaq.Location{}, false
+}
+
+// openAQData returns the data for the latest measurements of the location's sensors with concentrations
+// in the units airvisual uses
+func openAQData(location openaq.Location, latest []openaq.Latest, l *Location) *airvisual.Data {
+	data := &airvisual.Data{
+		Name:    location.Name,
+		City:    l.City,
+		State:   l.State,
+		Country: l.Country,
+		Location: airvisual.Location{
+			Type:        "Point",
+			Coordinates: []float64{location.Coordinates.Longitude, location.Coordinates.Latitude},
+		},
+	}
+	if len(data.City) == 0 {
+		data.City = location.Locality
+	}
+	p := &data.Current.Pollution
+	for _, m := range latest {
+		sensor, ok := location.Sensor(m.SensorsID)
+		if !ok {
+			continue
+		}
+		pollutant := pollutantFromCode(sensor.Parameter.Name)
+		if len(pollutant) == 0 {
+			continue
+		}
+		conc := &airvisual.Concentration{Value: convertUnits(pollutant, m.Value, sensor.Parameter.Units)}
+		switch pollutant {
+		case airvisual.PollutantPM25:
+			p.PM25 = conc
+		case airvisual.PollutantPM10:
+			p.PM10 = conc
+		case airvisual.PollutantO3:
+			p.O3 = conc
+		case airvisual.PollutantNO2:
+			p.NO2 = conc
+		case airvisual.PollutantSO2:
+			p.SO2 = conc
+		case airvisual.PollutantCO:
+			p.CO = conc
+		}
+		if m.Datetime.UTC.After(p.Time) {
+			p.Time = m.Datetime.UTC
+		}
+	}
+	return data
+}
+
+// convertUnits converts the value to ug/m3 for particulates, ppm for co and ppb for other gases
+func convertUnits(pollutant airvisual.Pollutant, value float64, unit string) float64 {
+	if !pollutant.IsGas() {
+		return value
+	}
+	ppb := value
+	switch unit {
+	case openaq.UnitPPM:
+		ppb = value * 1000
+	case openaq.UnitMicrogramsPerCubicMeter:
+		ppb = value * airvisual.MolarVolume / pollutant.MolecularWeight()
+	}
+	if pollutant == airvisual.PollutantCO {
+		return ppb / 1000
+	}
+	return ppb
+}
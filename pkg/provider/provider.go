@@ -0,0 +1,193 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+
+	exception "github.com/blend/go-sdk/exception"
+	logger "github.com/blend/go-sdk/logger"
+	"github.com/mat285/aqi/pkg/airvisual"
+	"github.com/mat285/aqi/pkg/config"
+)
+
+const (
+	// ErrNoProviders is returned when there are no providers to fetch from
+	ErrNoProviders exception.Class = "NoProviders"
+	// ErrRequiresCoordinates is returned when a provider can only look up coordinates
+	ErrRequiresCoordinates exception.Class = "RequiresCoordinates"
+	// ErrNoData is returned when a provider has no readings for the location
+	ErrNoData exception.Class = "NoData"
+	// ErrNoAQI is returned when a provider has readings but no aqi for the location
+	ErrNoAQI exception.Class = "NoAQI"
+)
+
+// Provider fetches the current air data for a location
+type Provider interface {
+	Name() string
+	Current(*Location) (*airvisual.Data, error)
+}
+
+// Location is a location to fetch air data for, by city or by coordinates
+type Location struct {
+	City        string
+	State       string
+	Country     string
+	Coordinates *Coordinates
+}
+
+// Coordinates are a latitude and longitude
+type Coordinates struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// CityLocation returns the location for the airvisual location request
+func CityLocation(r *airvisual.LocationRequest) *Location {
+	if r == nil {
+		return nil
+	}
+	return &Location{
+		City:    r.City,
+		State:   r.State,
+		Country: r.Country,
+	}
+}
+
+// CoordinatesLocation returns the location for the coordinates
+func CoordinatesLocation(lat, lon float64) *Location {
+	return &Location{
+		Coordinates: &Coordinates{Latitude: lat, Longitude: lon},
+	}
+}
+
+// HasCity returns if the location has a city
+func (l *Location) HasCity() bool {
+	return l != nil && len(l.City) > 0
+}
+
+// HasCoordinates returns if the location has coordinates
+func (l *Location) HasCoordinates() bool {
+	return l != nil && l.Coordinates != nil
+}
+
+// LocationRequest returns the airvisual location request for the location
+func (l *Location) LocationRequest() *airvisual.LocationRequest {
+	return &airvisual.LocationRequest{
+		City:    l.City,
+		State:   l.State,
+		Country: l.Country,
+	}
+}
+
+// String returns the display name of the location
+func (l *Location) String() string {
+	if l == nil {
+		return ""
+	}
+	if l.HasCity() {
+		return l.City
+	}
+	if l.HasCoordinates() {
+		return fmt.Sprintf("%v,%v", l.Coordinates.Latitude, l.Coordinates.Longitude)
+	}
+	return ""
+}
+
+// Key returns the normalized key of the location, coordinates are rounded to about a kilometer
+func (l *Location) Key() string {
+	if l == nil {
+		return ""
+	}
+	if l.HasCity() {
+		parts := []string{}
+		for _, p := range []string{l.City, l.State, l.Country} {
+			if p = strings.ToLower(strings.TrimSpace(p)); len(p) > 0 {
+				parts = append(parts, p)
+			}
+		}
+		return strings.Join(parts, ", ")
+	}
+	if l.HasCoordinates() {
+		return fmt.Sprintf("%.2f,%.2f", l.Coordinates.Latitude, l.Coordinates.Longitude)
+	}
+	return ""
+}
+
+// Fallback tries each provider in order until one returns data
+type Fallback struct {
+	Providers []Provider
+	Log       *logger.Logger
+}
+
+// Name returns the name of the provider
+func (f *Fallback) Name() string {
+	names := make([]string, 0, len(f.Providers))
+	for _, p := range f.Providers {
+		names = append(names, p.Name())
+	}
+	return strings.Join(names, ",")
+}
+
+// Current returns the current air data from the first provider to succeed
+func (f *Fallback) Current(l *Location) (*airvisual.Data, error) {
+	if len(f.Providers) == 0 {
+		return nil, exception.New(ErrNoProviders)
+	}
+	errs := []error{}
+	for _, p := range f.Providers {
+		data, err := p.Current(l)
+		if err == nil {
+			return data, nil
+		}
+		if f.Log != nil {
+			f.Log.SyncWarningf("Provider `%s` failed for `%s`: %v", p.Name(), l, err)
+		}
+		errs = append(errs, err)
+	}
+	return nil, exception.Nest(errs...)
+}
+
+// NewFromConfig returns the provider for the config, falling back between the configured providers in order
+func NewFromConfig(c *config.Config, log *logger.Logger) (Provider, error) {
+	err := c.ValidateProviders()
+	if err != nil {
+		return nil, err
+	}
+	f := &Fallback{Log: log}
+	for _, name := range c.GetProviders() {
+		switch name {
+		case config.ProviderAirVisual:
+			client, err := AirVisualClient(c, log)
+			if err != nil {
+				return nil, err
+			}
+			f.Providers = append(f.Providers, &AirVisual{Client: client})
+		case config.ProviderAirNow:
+			f.Providers = append(f.Providers, NewAirNow(c.AirNowAPIKey))
+		case config.ProviderOpenAQ:
+			f.Providers = append(f.Providers, NewOpenAQ(c.OpenAQAPIKey))
+		case config.ProviderWAQI:
+			f.Providers = append(f.Providers, NewWAQI(c.WAQIToken))
+		}
+	}
+	return f, nil
+}
+
+// pollutantFromCode returns the airvisual pollutant for a provider's parameter code
+func pollutantFromCode(code string) airvisual.Pollutant {
+	switch strings.Replace(strings.ToLower(code), ".", "", -1) {
+	case "pm25":
+		return airvisual.PollutantPM25
+	case "pm10":
+		return airvisual.PollutantPM10
+	case "o3", "ozone":
+		return airvisual.PollutantO3
+	case "no2":
+		return airvisual.PollutantNO2
+	case "so2":
+		return airvisual.PollutantSO2
+	case "co":
+		return airvisual.PollutantCO
+	}
+	return ""
+}
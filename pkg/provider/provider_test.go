@@ -0,0 +1,135 @@
+package provider
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/mat285/aqi/pkg/airnow"
+	"github.com/mat285/aqi/pkg/airvisual"
+	"github.com/mat285/aqi/pkg/epa"
+	"github.com/mat285/aqi/pkg/openaq"
+	"github.com/mat285/aqi/pkg/waqi"
+)
+
+// readFixture reads the json fixture of a client package's testdata
+func readFixture(t *testing.T, path string, v interface{}) {
+	t.Helper()
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAirNowData(t *testing.T) {
+	obs := []airnow.Observation{}
+	readFixture(t, "../airnow/testdata/observations.json", &obs)
+	data, err := airNowData(obs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := data.Current.Pollution
+	if p.AQI != 151 || p.MainPollutant != airvisual.PollutantPM25 || !p.Time.Equal(time.Date(2020, time.September, 7, 20, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected the worst parameter's aqi, got %+v", p)
+	}
+	if data.City != "San Francisco" || data.State != "CA" || data.Country != "USA" || data.Location.Coordinates[0] != -122.43 {
+		t.Errorf("unexpected location %+v", data)
+	}
+}
+
+func TestWAQIData(t *testing.T) {
+	resp := &waqi.Response{}
+	readFixture(t, "../waqi/testdata/feed.json", resp)
+	feed, err := resp.Feed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := waqiData(feed, CoordinatesLocation(37.76, -122.4))
+	p := data.Current.Pollution
+	if p.AQI != 153 || p.MainPollutant != airvisual.PollutantPM25 {
+		t.Errorf("unexpected pollution %+v", p)
+	}
+	if data.City != feed.City.Name || data.Location.Coordinates[0] != -122.3978 || data.Location.Coordinates[1] != 37.7658 {
+		t.Errorf("expected the station's name and coordinates for a coordinates lookup, got %+v", data)
+	}
+	w := data.Current.Weather
+	if w.Temperature != 24 || w.Humidity != 57 || w.Pressure != 1013 || w.WindSpeed != 3.6 {
+		t.Errorf("unexpected weather %+v", w)
+	}
+
+	named := waqiData(feed, &Location{City: "San Francisco", State: "California", Country: "USA"})
+	if named.City != "San Francisco" || named.Name != feed.City.Name {
+		t.Errorf("expected the requested city to be kept, got %+v", named)
+	}
+}
+
+func TestOpenAQData(t *testing.T) {
+	locations := &openaq.LocationsResponse{}
+	readFixture(t, "../openaq/testdata/locations.json", locations)
+	latest := &openaq.LatestResponse{}
+	readFixture(t, "../openaq/testdata/latest.json", latest)
+
+	now := time.Date(2020, time.September, 7, 20, 0, 0, 0, time.UTC)
+	location, ok := nearestReporting(locations.Results, now)
+	if !ok || location.ID != 8881 {
+		t.Fatalf("expected the nearest location still reporting, got %+v", location)
+	}
+	if _, ok := nearestReporting(locations.Results, now.Add(OpenAQStaleAfter+time.Hour)); ok {
+		t.Error("expected no location once every location's measurements are stale")
+	}
+
+	data := openAQData(location, latest.Results, CoordinatesLocation(37.76, -122.4))
+	p := data.Current.Pollution
+	cases := []struct {
+		pollutant airvisual.Pollutant
+		expected  float64
+	}{
+		{airvisual.PollutantPM25, 35.5},
+		{airvisual.PollutantO3, 71},
+		{airvisual.PollutantNO2, 12},
+		{airvisual.PollutantCO, 0.4},
+	}
+	for _, c := range cases {
+		conc := p.Concentration(c.pollutant)
+		if conc == nil || math.Abs(conc.Value-c.expected) > 1e-9 {
+			t.Errorf("%s: got %+v, expected %v", c.pollutant, conc, c.expected)
+		}
+	}
+	if p.PM10 != nil || p.SO2 != nil {
+		t.Errorf("expected no concentrations without sensors, got %+v", p)
+	}
+	if !p.Time.Equal(time.Date(2020, time.September, 7, 19, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected the time of the latest measurement, got %s", p.Time)
+	}
+	if data.City != location.Locality || data.Name != location.Name {
+		t.Errorf("expected the location's locality for a coordinates lookup, got %+v", data)
+	}
+	if !epa.NormalizeData(data, now) || data.Current.Pollution.AQI == 0 {
+		t.Errorf("expected an aqi from the concentrations, got %+v", data.Current.Pollution)
+	}
+}
+
+func TestConvertUnits(t *testing.T) {
+	cases := []struct {
+		pollutant airvisual.Pollutant
+		value     float64
+		unit      string
+		expected  float64
+	}{
+		{airvisual.PollutantPM25, 12, openaq.UnitMicrogramsPerCubicMeter, 12},
+		{airvisual.PollutantO3, 0.07, openaq.UnitPPM, 70},
+		{airvisual.PollutantNO2, 20, openaq.UnitPPB, 20},
+		{airvisual.PollutantCO, 2000, openaq.UnitPPB, 2},
+		{airvisual.PollutantSO2, airvisual.PollutantSO2.MolecularWeight(), openaq.UnitMicrogramsPerCubicMeter, airvisual.MolarVolume},
+	}
+	for _, c := range cases {
+		if converted := convertUnits(c.pollutant, c.value, c.unit); math.Abs(converted-c.expected) > 1e-9 {
+			t.Errorf("%v %s of %s: converted to %v, expected %v", c.value, c.unit, c.pollutant, converted, c.expected)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package provider
+
+import (
+	exception "github.com/blend/go-sdk/exception"
+	"github.com/mat285/aqi/pkg/airvisual"
+	"github.com/mat285/aqi/pkg/waqi"
+)
+
+// WAQI provides air data from the world air quality index project
+type WAQI struct {
+	Client *waqi.Client
+}
+
+// NewWAQI returns a new waqi provider
+func NewWAQI(token string) *WAQI {
+	return &WAQI{Client: waqi.New(token)}
+}
+
+// Name returns the name of the provider
+func (w *WAQI) Name() string {
+	return "waqi"
+}
+
+// Current returns the current air data for the location
+func (w *WAQI) Current(l *Location) (*airvisual.Data, error) {
+	var feed *waqi.Feed
+	var err error
+	if l.HasCoordinates() {
+		feed, err = w.Client.Geo(l.Coordinates.Latitude, l.Coordinates.Longitude)
+	} else if l.HasCity() {
+		feed, err = w.Client.City(l.City)
+	} else {
+		return nil, exception.New("MissingLocation")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if feed.AQI < 0 {
+		return nil, exception.New(ErrNoAQI).WithMessagef("%s has no aqi for %s", w.Name(), l)
+	}
+	return waqiData(feed, l), nil
+}
+
+// waqiData returns the data for the feed, waqi reports pollutants as aqi rather than concentrations
+func waqiData(feed *waqi.Feed, l *Location) *airvisual.Data {
+	data := &airvisual.Data{
+		Name:    feed.City.Name,
+		City:    l.City,
+		State:   l.State,
+		Country: l.Country,
+	}
+	if len(data.City) == 0 {
+		data.City = feed.City.Name
+	}
+	if len(feed.City.Geo) == 2 {
+		data.Location = airvisual.Location{
+			Type:        "Point",
+			Coordinates: []float64{feed.City.Geo[1], feed.City.Geo[0]},
+		}
+	}
+	data.Current.Pollution = airvisual.Pollution{
+		Time:          feed.Time.ISO,
+		AQI:           int(feed.AQI),
+		MainPollutant: pollutantFromCode(feed.DominantPollutant),
+	}
+	data.Current.Weather = airvisual.Weather{
+		Time:        feed.Time.ISO,
+		Temperature: int(feed.IAQI["t"].V),
+		Humidity:    int(feed.IAQI["h"].V),
+		Pressure:    int(feed.IAQI["p"].V),
+		WindSpeed:   float32(feed.IAQI["w"].V),
+	}
+	return data
+}
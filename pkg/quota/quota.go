@@ -0,0 +1,197 @@
+package quota
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	exception "github.com/blend/go-sdk/exception"
+	logger "github.com/blend/go-sdk/logger"
+	"github.com/mat285/aqi/pkg/fileutil"
+)
+
+const (
+	// ErrRateLimited is returned when the per minute budget is used up
+	ErrRateLimited exception.Class = "RateLimited"
+	// ErrQuotaExceeded is returned when the per month budget is used up
+	ErrQuotaExceeded exception.Class = "QuotaExceeded"
+
+	// MonthFormat is the format of the month usage is counted in
+	MonthFormat = "2006-01"
+)
+
+// Usage is the number of calls made in the current minute and month
+type Usage struct {
+	Minute      time.Time `json:"minute"`
+	MinuteCalls int       `json:"minuteCalls"`
+	Month       string    `json:"month"`
+	MonthCalls  int       `json:"monthCalls"`
+}
+
+// Limiter limits calls to per minute and per month budgets, persisting usage to a json file if it has one,
+// a budget of zero is unlimited. Processes sharing the file see each other's calls, since the usage is
+// reread from the file before each call is counted while holding a lock on the file
+type Limiter struct {
+	sync.Mutex
+	PerMinute int            `json:"-"`
+	PerMonth  int            `json:"-"`
+	Usage     Usage          `json:"usage"`
+	Log       *logger.Logger `json:"-"`
+	path      string
+	now       func() time.Time
+}
+
+// New returns a new limiter that only counts usage in memory
+func New(perMinute, perMonth int) *Limiter {
+	return &Limiter{PerMinute: perMinute, PerMonth: perMonth, now: time.Now}
+}
+
+// Load loads the limiter with the usage persisted to the file, a missing file is no usage
+func Load(path string, perMinute, perMonth int) (*Limiter, error) {
+	l := New(perMinute, perMonth)
+	l.path = path
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return l, nil
+	} else if err != nil {
+		return nil, exception.New(err)
+	}
+	return l, exception.New(json.Unmarshal(data, l))
+}
+
+// Take takes a call from the budgets, returning an error without counting the call if either is used up
+func (l *Limiter) Take() error {
+	l.Lock()
+	defer l.Unlock()
+	unlock := l.lockFile()
+	defer unlock()
+	now := l.now().UTC()
+	l.reload()
+	l.roll(now)
+	if l.PerMonth > 0 && l.Usage.MonthCalls >= l.PerMonth {
+		return exception.New(ErrQuotaExceeded).WithMessagef("%d of %d calls made in %s", l.Usage.MonthCalls, l.PerMonth, l.Usage.Month)
+	}
+	if l.PerMinute > 0 && l.Usage.MinuteCalls >= l.PerMinute {
+		return exception.New(ErrRateLimited).WithMessagef("%d of %d calls made this minute", l.Usage.MinuteCalls, l.PerMinute)
+	}
+	l.Usage.MinuteCalls++
+	l.Usage.MonthCalls++
+	// the call is still made if usage can't be saved, a full or read only disk shouldn't stop lookups
+	if err := l.save(); err != nil && l.Log != nil {
+		l.Log.SyncError(err)
+	}
+	return nil
+}
+
+// Current returns the usage in the current minute and month
+func (l *Limiter) Current() Usage {
+	l.Lock()
+	defer l.Unlock()
+	l.reload()
+	l.roll(l.now().UTC())
+	return l.Usage
+}
+
+// lockFile locks the file so other processes can't count calls until this one's is saved, the call
+// is counted without the lock if it can't be taken
+func (l *Limiter) lockFile() func() {
+	if len(l.path) == 0 {
+		return func() {}
+	}
+	unlock, err := fileutil.Lock(l.path)
+	if err != nil {
+		if l.Log != nil {
+			l.Log.SyncError(err)
+		}
+		return func() {}
+	}
+	return unlock
+}
+
+// reload takes the usage saved to the file by other processes, keeping the higher counts
+// of the same minute and month, the usage in memory is kept if the file can't be read
+func (l *Limiter) reload() {
+	if len(l.path) == 0 {
+		return
+	}
+	data, err := ioutil.ReadFile(l.path)
+	if err != nil {
+		return
+	}
+	saved := &Limiter{}
+	if json.Unmarshal(data, saved) != nil {
+		return
+	}
+	if saved.Usage.Minute.After(l.Usage.Minute) || saved.Usage.Minute.Equal(l.Usage.Minute) && saved.Usage.MinuteCalls > l.Usage.MinuteCalls {
+		l.Usage.Minute, l.Usage.MinuteCalls = saved.Usage.Minute, saved.Usage.MinuteCalls
+	}
+	if saved.Usage.Month > l.Usage.Month || saved.Usage.Month == l.Usage.Month && saved.Usage.MonthCalls > l.Usage.MonthCalls {
+		l.Usage.Month, l.Usage.MonthCalls = saved.Usage.Month, saved.Usage.MonthCalls
+	}
+}
+
+// roll resets the counts once their minute or month has passed
+func (l *Limiter) roll(now time.Time) {
+	if minute := now.Truncate(time.Minute); !minute.Equal(l.Usage.Minute) {
+		l.Usage.Minute = minute
+		l.Usage.MinuteCalls = 0
+	}
+	if month := now.Format(MonthFormat); month != l.Usage.Month {
+		l.Usage.Month = month
+		l.Usage.MonthCalls = 0
+	}
+}
+
+// save writes the usage to the file, replacing it atomically
+func (l *Limiter) save() error {
+	if len(l.path) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(l)
+	if err != nil {
+		return exception.New(err)
+	}
+	return fileutil.WriteAtomic(l.path, data)
+}
+
+// Exhausted returns which budget was used up if the error or any error nested in it is from a used up budget
+func Exhausted(err error) (exception.Class, bool) {
+	for ; err != nil; err = exception.Inner(err) {
+		for _, class := range []exception.Class{ErrRateLimited, ErrQuotaExceeded} {
+			if exception.Is(err, class) {
+				return class, true
+			}
+		}
+	}
+	return "", false
+}
+
+var (
+	sharedLock sync.Mutex
+	shared     = map[string]*Limiter{}
+)
+
+// Shared returns the limiter for the file shared by everything in the process, loading it the first time,
+// failures to save usage are logged to the log if there is one
+func Shared(path string, perMinute, perMonth int, log *logger.Logger) (*Limiter, error) {
+	sharedLock.Lock()
+	defer sharedLock.Unlock()
+	if l, ok := shared[path]; ok {
+		l.Lock()
+		l.PerMinute, l.PerMonth = perMinute, perMonth
+		if log != nil {
+			l.Log = log
+		}
+		l.Unlock()
+		return l, nil
+	}
+	l, err := Load(path, perMinute, perMonth)
+	if err != nil {
+		return nil, err
+	}
+	l.Log = log
+	shared[path] = l
+	return l, nil
+}
@@ -0,0 +1,110 @@
+package quota
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2020, time.September, 30, 23, 59, 0, 0, time.UTC)
+
+func clock(l *Limiter, t *time.Time) {
+	l.now = func() time.Time { return *t }
+}
+
+func TestTakeRollsOver(t *testing.T) {
+	now := start
+	l := New(2, 3)
+	clock(l, &now)
+	for i := 0; i < 2; i++ {
+		if err := l.Take(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := l.Take()
+	if class, ok := Exhausted(err); !ok || class != ErrRateLimited {
+		t.Errorf("expected the minute's budget to be used up, got %v", err)
+	}
+
+	now = start.Add(time.Minute)
+	if err := l.Take(); err != nil {
+		t.Errorf("expected the minute's budget to reset in the next minute, got %v", err)
+	}
+	if usage := l.Current(); usage.MinuteCalls != 1 || usage.Month != "2020-10" || usage.MonthCalls != 1 {
+		t.Errorf("expected the month's count to reset in the next month, got %+v", usage)
+	}
+}
+
+func TestTakeExhausted(t *testing.T) {
+	now := start.Add(-time.Hour)
+	l := New(0, 3)
+	clock(l, &now)
+	for i := 0; i < 3; i++ {
+		now = now.Add(time.Minute)
+		if err := l.Take(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now = now.Add(time.Minute)
+	err := l.Take()
+	if class, ok := Exhausted(err); !ok || class != ErrQuotaExceeded {
+		t.Errorf("expected the month's budget to be used up, got %v", err)
+	}
+	if usage := l.Current(); usage.MonthCalls != 3 || usage.MinuteCalls != 0 {
+		t.Errorf("expected a refused call not to be counted, got %+v", usage)
+	}
+	if _, ok := Exhausted(nil); ok {
+		t.Error("expected no budget used up without an error")
+	}
+}
+
+func TestTakeShared(t *testing.T) {
+	dir, err := ioutil.TempDir("", "quota")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "quota.json")
+	now := start.Add(-time.Hour)
+	limiters := []*Limiter{}
+	for i := 0; i < 2; i++ {
+		l, err := Load(path, 0, 50)
+		if err != nil {
+			t.Fatal(err)
+		}
+		clock(l, &now)
+		limiters = append(limiters, l)
+	}
+
+	var wg sync.WaitGroup
+	var taken sync.Map
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if limiters[i%2].Take() == nil {
+				taken.Store(i, true)
+			}
+		}(i)
+	}
+	wg.Wait()
+	count := 0
+	taken.Range(func(interface{}, interface{}) bool {
+		count++
+		return true
+	})
+	if count != 50 {
+		t.Errorf("expected the limiters sharing a file to take 50 calls between them, took %d", count)
+	}
+
+	saved, err := Load(path, 0, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Usage.MonthCalls != 50 {
+		t.Errorf("expected every call to be saved, got %+v", saved.Usage)
+	}
+}
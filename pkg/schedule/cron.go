@@ -0,0 +1,155 @@
+package schedule
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	exception "github.com/blend/go-sdk/exception"
+)
+
+const (
+	// ErrInvalidCron is returned when a cron expression cannot be parsed
+	ErrInvalidCron exception.Class = "InvalidCron"
+)
+
+var (
+	shortcuts = map[string]string{
+		"@yearly":   "0 0 1 1 *",
+		"@annually": "0 0 1 1 *",
+		"@monthly":  "0 0 1 * *",
+		"@weekly":   "0 0 * * 0",
+		"@daily":    "0 0 * * *",
+		"@midnight": "0 0 * * *",
+		"@hourly":   "0 * * * *",
+	}
+
+	monthNames = map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}
+
+	dayNames = map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}
+)
+
+// Cron is a parsed five field cron expression: minute, hour, day of month, month and day of week
+type Cron struct {
+	expr       string
+	minutes    uint64
+	hours      uint64
+	daysOfMon  uint64
+	months     uint64
+	daysOfWeek uint64
+	// anyDay is set when either day field is `*`, otherwise a time matches if either day field matches
+	anyDay bool
+}
+
+// ParseCron parses a cron expression, the @hourly style shortcuts are supported
+func ParseCron(expr string) (*Cron, error) {
+	expr = strings.TrimSpace(expr)
+	fields := strings.Fields(expr)
+	if len(fields) == 1 {
+		if full, ok := shortcuts[strings.ToLower(fields[0])]; ok {
+			fields = strings.Fields(full)
+		}
+	}
+	if len(fields) != 5 {
+		return nil, exception.New(ErrInvalidCron).WithMessagef("expected 5 fields in `%s`", expr)
+	}
+	c := &Cron{expr: expr}
+	var err error
+	if c.minutes, err = parseField(fields[0], 0, 59, nil); err != nil {
+		return nil, err
+	}
+	if c.hours, err = parseField(fields[1], 0, 23, nil); err != nil {
+		return nil, err
+	}
+	if c.daysOfMon, err = parseField(fields[2], 1, 31, nil); err != nil {
+		return nil, err
+	}
+	if c.months, err = parseField(fields[3], 1, 12, monthNames); err != nil {
+		return nil, err
+	}
+	if c.daysOfWeek, err = parseField(fields[4], 0, 7, dayNames); err != nil {
+		return nil, err
+	}
+	// sunday is both 0 and 7
+	if c.daysOfWeek&(1<<7) != 0 {
+		c.daysOfWeek |= 1
+	}
+	c.anyDay = strings.HasPrefix(fields[2], "*") || strings.HasPrefix(fields[4], "*")
+	return c, nil
+}
+
+// String returns the cron expression
+func (c *Cron) String() string {
+	return c.expr
+}
+
+// Matches returns if the cron fires in the minute of the time
+func (c *Cron) Matches(t time.Time) bool {
+	if !has(c.minutes, t.Minute()) || !has(c.hours, t.Hour()) || !has(c.months, int(t.Month())) {
+		return false
+	}
+	dom := has(c.daysOfMon, t.Day())
+	dow := has(c.daysOfWeek, int(t.Weekday()))
+	if c.anyDay {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+func has(set uint64, v int) bool {
+	return set&(1<<uint(v)) != 0
+}
+
+func parseField(field string, min, max int, names map[string]int) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			s, err := strconv.Atoi(part[i+1:])
+			if err != nil || s <= 0 {
+				return 0, exception.New(ErrInvalidCron).WithMessagef("invalid step in `%s`", field)
+			}
+			step = s
+			part = part[:i]
+		}
+		low, high := min, max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if low, err = parseValue(bounds[0], names); err != nil {
+				return 0, err
+			}
+			high = low
+			if len(bounds) == 2 {
+				if high, err = parseValue(bounds[1], names); err != nil {
+					return 0, err
+				}
+			} else if step > 1 {
+				high = max
+			}
+		}
+		if low < min || high > max || low > high {
+			return 0, exception.New(ErrInvalidCron).WithMessagef("`%s` is out of range %d-%d", field, min, max)
+		}
+		for v := low; v <= high; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+func parseValue(value string, names map[string]int) (int, error) {
+	if v, ok := names[strings.ToLower(value)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, exception.New(ErrInvalidCron).WithMessagef("invalid value `%s`", value)
+	}
+	return v, nil
+}
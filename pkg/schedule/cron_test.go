@@ -0,0 +1,97 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"@fortnightly",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"a * * * *",
+		"1,,2 * * * *",
+	} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) expected an error", expr)
+		}
+	}
+}
+
+func TestCronMatches(t *testing.T) {
+	// 2020-09-07 is a monday
+	at := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2020, month, day, hour, minute, 0, 0, time.UTC)
+	}
+	cases := []struct {
+		name  string
+		expr  string
+		time  time.Time
+		match bool
+	}{
+		{"every minute", "* * * * *", at(time.September, 7, 13, 37), true},
+		{"exact minute", "30 9 * * *", at(time.September, 7, 9, 30), true},
+		{"exact minute wrong hour", "30 9 * * *", at(time.September, 7, 10, 30), false},
+		{"hourly", "@hourly", at(time.September, 7, 5, 0), true},
+		{"hourly off the hour", "@hourly", at(time.September, 7, 5, 1), false},
+		{"daily", "@daily", at(time.September, 7, 0, 0), true},
+		{"weekly on sunday", "@weekly", at(time.September, 6, 0, 0), true},
+		{"weekly on monday", "@weekly", at(time.September, 7, 0, 0), false},
+		{"shortcut is case insensitive", "@Monthly", at(time.October, 1, 0, 0), true},
+
+		{"range start", "0 9-17 * * *", at(time.September, 7, 9, 0), true},
+		{"range end", "0 9-17 * * *", at(time.September, 7, 17, 0), true},
+		{"range before", "0 9-17 * * *", at(time.September, 7, 8, 0), false},
+		{"range after", "0 9-17 * * *", at(time.September, 7, 18, 0), false},
+
+		{"step from star", "*/15 * * * *", at(time.September, 7, 9, 45), true},
+		{"step from star off step", "*/15 * * * *", at(time.September, 7, 9, 50), false},
+		{"step over range", "10-30/10 * * * *", at(time.September, 7, 9, 20), true},
+		{"step over range past end", "10-30/10 * * * *", at(time.September, 7, 9, 40), false},
+		{"step from value runs to max", "5/20 * * * *", at(time.September, 7, 9, 45), true},
+		{"step from value before start", "5/20 * * * *", at(time.September, 7, 9, 0), false},
+
+		{"list first", "0,20,40 * * * *", at(time.September, 7, 9, 0), true},
+		{"list last", "0,20,40 * * * *", at(time.September, 7, 9, 40), true},
+		{"list missing", "0,20,40 * * * *", at(time.September, 7, 9, 30), false},
+		{"list of ranges", "0 1-3,20-22 * * *", at(time.September, 7, 21, 0), true},
+
+		{"month name", "0 0 1 sep *", at(time.September, 1, 0, 0), true},
+		{"month name range", "0 0 1 jan-mar *", at(time.September, 1, 0, 0), false},
+		{"day name range", "0 9 * * mon-fri", at(time.September, 7, 9, 0), true},
+		{"day name range weekend", "0 9 * * mon-fri", at(time.September, 6, 9, 0), false},
+		{"sunday as 7", "0 9 * * 7", at(time.September, 6, 9, 0), true},
+		{"sunday as 0", "0 9 * * 0", at(time.September, 6, 9, 0), true},
+
+		{"day of month only", "0 9 15 * *", at(time.September, 15, 9, 0), true},
+		{"day of month only wrong day", "0 9 15 * *", at(time.September, 14, 9, 0), false},
+		{"day of week only", "0 9 * * 1", at(time.September, 14, 9, 0), true},
+		{"day of week only wrong day", "0 9 * * 1", at(time.September, 15, 9, 0), false},
+		{"both days match day of month", "0 9 15 * 1", at(time.September, 15, 9, 0), true},
+		{"both days match day of week", "0 9 15 * 1", at(time.September, 7, 9, 0), true},
+		{"both days match neither", "0 9 15 * 1", at(time.September, 16, 9, 0), false},
+		{"stepped day of month is any day", "0 9 */2 * 1", at(time.September, 14, 9, 0), false},
+		{"stepped day of month and day of week both match", "0 9 */2 * 1", at(time.September, 21, 9, 0), true},
+	}
+	for _, c := range cases {
+		cron, err := ParseCron(c.expr)
+		if err != nil {
+			t.Errorf("%s: ParseCron(%q) = %v", c.name, c.expr, err)
+			continue
+		}
+		if match := cron.Matches(c.time); match != c.match {
+			t.Errorf("%s: %q matches %s = %v, expected %v", c.name, c.expr, c.time, match, c.match)
+		}
+	}
+}
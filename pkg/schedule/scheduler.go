@@ -0,0 +1,136 @@
+package schedule
+
+import (
+	"time"
+
+	"github.com/blend/go-sdk/async"
+	logger "github.com/blend/go-sdk/logger"
+)
+
+const (
+	// MaxCatchUp is the most minutes the scheduler will catch up on if a tick is delayed
+	MaxCatchUp = 60
+)
+
+// Job is an action run on a cron schedule
+type Job struct {
+	Name   string
+	Cron   *Cron
+	Action func() error
+}
+
+// Scheduler runs jobs on their cron schedules, checking every minute.
+// It blocks in Start so it can be hosted by graceful.
+type Scheduler struct {
+	jobs     []*Job
+	log      *logger.Logger
+	location *time.Location
+	interval *async.Interval
+	latch    *async.Latch
+	last     time.Time
+}
+
+// New returns a new scheduler
+func New(log *logger.Logger) *Scheduler {
+	s := &Scheduler{
+		log:      log,
+		location: time.UTC,
+		latch:    async.NewLatch(),
+	}
+	s.interval = async.NewInterval(s.tick, time.Minute)
+	return s
+}
+
+// WithLocation sets the timezone the cron schedules are evaluated in
+func (s *Scheduler) WithLocation(location *time.Location) *Scheduler {
+	s.location = location
+	return s
+}
+
+// Add adds a job to the scheduler
+func (s *Scheduler) Add(name string, cron *Cron, action func() error) *Scheduler {
+	s.jobs = append(s.jobs, &Job{Name: name, Cron: cron, Action: action})
+	return s
+}
+
+// Jobs returns the scheduled jobs
+func (s *Scheduler) Jobs() []*Job {
+	return s.jobs
+}
+
+// Start starts the scheduler and blocks until it is stopped
+func (s *Scheduler) Start() error {
+	now := time.Now().In(s.location)
+	s.last = now.Truncate(time.Minute)
+	// the interval first ticks a full interval after the delay, so delay to land ticks a second past the start of a minute
+	delay := s.last.Add(time.Minute).Sub(now) + time.Second
+	if delay >= time.Minute {
+		delay -= time.Minute
+	}
+	err := s.interval.WithDelay(delay).Start()
+	if err != nil {
+		return err
+	}
+	s.latch.Started()
+	if s.log != nil {
+		s.log.SyncInfof("Scheduler started with %d jobs", len(s.jobs))
+	}
+	<-s.latch.NotifyStopping()
+	err = s.interval.Stop()
+	s.latch.Stopped()
+	return err
+}
+
+// Stop stops the scheduler, waiting for any running jobs to finish
+func (s *Scheduler) Stop() error {
+	if !s.latch.CanStop() {
+		return nil
+	}
+	s.latch.Stopping()
+	<-s.latch.NotifyStopped()
+	return nil
+}
+
+// NotifyStarted returns the started signal
+func (s *Scheduler) NotifyStarted() <-chan struct{} {
+	return s.latch.NotifyStarted()
+}
+
+// NotifyStopped returns the stopped signal
+func (s *Scheduler) NotifyStopped() <-chan struct{} {
+	return s.latch.NotifyStopped()
+}
+
+// tick runs the jobs due in each minute since the last tick
+func (s *Scheduler) tick() error {
+	s.catchUp(time.Now().In(s.location).Truncate(time.Minute))
+	return nil
+}
+
+// catchUp runs the jobs due in each minute after the last tick up to and including now, at most MaxCatchUp minutes
+func (s *Scheduler) catchUp(now time.Time) {
+	from := s.last.Add(time.Minute)
+	if now.Sub(from) > MaxCatchUp*time.Minute {
+		from = now.Add(-MaxCatchUp * time.Minute)
+	}
+	for minute := from; !minute.After(now); minute = minute.Add(time.Minute) {
+		s.RunDue(minute)
+	}
+	s.last = now
+}
+
+// RunDue runs the jobs whose schedules match the minute
+func (s *Scheduler) RunDue(minute time.Time) {
+	for _, job := range s.jobs {
+		if !job.Cron.Matches(minute) {
+			continue
+		}
+		if s.log != nil {
+			s.log.SyncInfof("Running scheduled job `%s`", job.Name)
+		}
+		err := job.Action()
+		if err != nil && s.log != nil {
+			s.log.SyncError(err)
+		}
+	}
+}
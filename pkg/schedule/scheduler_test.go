@@ -0,0 +1,74 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	exception "github.com/blend/go-sdk/exception"
+)
+
+func mustParseCron(t *testing.T, expr string) *Cron {
+	t.Helper()
+	c, err := ParseCron(expr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestRunDue(t *testing.T) {
+	ran := []string{}
+	s := New(nil).
+		Add("hourly", mustParseCron(t, "@hourly"), func() error {
+			ran = append(ran, "hourly")
+			return nil
+		}).
+		Add("failing", mustParseCron(t, "* * * * *"), func() error {
+			ran = append(ran, "failing")
+			return exception.New("failed")
+		}).
+		Add("never", mustParseCron(t, "0 0 1 1 *"), func() error {
+			ran = append(ran, "never")
+			return nil
+		})
+
+	s.RunDue(time.Date(2020, time.September, 7, 10, 0, 0, 0, time.UTC))
+	if len(ran) != 2 || ran[0] != "hourly" || ran[1] != "failing" {
+		t.Errorf("expected the hourly and failing jobs to run in order, ran %v", ran)
+	}
+}
+
+func TestCatchUp(t *testing.T) {
+	last := time.Date(2020, time.September, 7, 9, 58, 0, 0, time.UTC)
+	cases := []struct {
+		name   string
+		now    time.Time
+		minute int
+		hourly int
+	}{
+		{"same minute", last, 0, 0},
+		{"on time", last.Add(time.Minute), 1, 0},
+		{"delayed over the hour", last.Add(5 * time.Minute), 5, 1},
+		{"delayed past the catch up limit", last.Add(3 * time.Hour), MaxCatchUp + 1, 1},
+	}
+	for _, c := range cases {
+		minute, hourly := 0, 0
+		s := New(nil).
+			Add("every minute", mustParseCron(t, "* * * * *"), func() error {
+				minute++
+				return nil
+			}).
+			Add("hourly", mustParseCron(t, "@hourly"), func() error {
+				hourly++
+				return nil
+			})
+		s.last = last
+		s.catchUp(c.now)
+		if minute != c.minute || hourly != c.hourly {
+			t.Errorf("%s: ran every minute %d and hourly %d times, expected %d and %d", c.name, minute, hourly, c.minute, c.hourly)
+		}
+		if !s.last.Equal(c.now) {
+			t.Errorf("%s: last tick %s, expected %s", c.name, s.last, c.now)
+		}
+	}
+}
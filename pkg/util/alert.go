@@ -0,0 +1,99 @@
+package util
+
+import (
+	"fmt"
+
+	logger "github.com/blend/go-sdk/logger"
+	"github.com/mat285/aqi/pkg/airvisual"
+	"github.com/mat285/aqi/pkg/alert"
+	"github.com/mat285/aqi/pkg/config"
+	"github.com/mat285/aqi/pkg/index"
+	"github.com/mat285/aqi/pkg/provider"
+	"github.com/mat285/slack/slack"
+)
+
+const (
+	// AlertEmoji is the emoji for an aqi rising above a threshold
+	AlertEmoji = ":rotating_light:"
+	// ClearEmoji is the emoji for an aqi dropping back below a threshold
+	ClearEmoji = ":white_check_mark:"
+)
+
+// AlertText returns the text for the aqi moving from the previous alert level to the level
+func AlertText(city string, t alert.Thresholds, previous, level int) string {
+	if level > previous {
+		return fmt.Sprintf("%s %s AQI rose above `%d`", AlertEmoji, city, t.RaiseValue(level))
+	}
+	return fmt.Sprintf("%s %s AQI dropped back below `%d`", ClearEmoji, city, t.ClearValue(level+1))
+}
+
+// AlertSlackMessage returns the message for the aqi moving from the previous alert level to the level
+func AlertSlackMessage(d *airvisual.Data, city string, idx index.Index, t alert.Thresholds, previous, level int) *slack.Message {
+	m := IndexSlackMessage(d, city, idx)
+	text := AlertText(city, t, previous, level)
+	m.Text = fmt.Sprintf("%s\n%s", text, m.Text)
+	m.Blocks = append(m.Blocks, slack.NewSectionBlock(text))
+	return m
+}
+
+// LocationKey returns the key identifying the location, cities are keyed by their full name and
+// coordinates by their rounded key
+func LocationKey(loc *provider.Location) string {
+	if !loc.HasCity() {
+		return loc.Key()
+	}
+	return fmt.Sprintf("%s, %s, %s", loc.City, loc.State, loc.Country)
+}
+
+// LocationName returns the name to show for the location, the city the data is for when it was looked up by coordinates
+func LocationName(loc *provider.Location, d *airvisual.Data) string {
+	if loc.HasCity() {
+		return loc.City
+	}
+	return d.City
+}
+
+// FetchAndAlertAQIForConfig fetches aqi and sends it for the config only if it crossed an alert threshold
+// since the last alert, it returns the aqi and if an alert was sent
+func FetchAndAlertAQIForConfig(c *config.Config, req *airvisual.LocationRequest, log *logger.Logger) (int, bool, error) {
+	return FetchAndAlertAQIForReport(c, DefaultReport(c), provider.CityLocation(req), log)
+}
+
+// FetchAndAlertAQIForReport fetches aqi and sends it to the report's webhook and channel only if it crossed
+// an alert threshold since the last alert to the channel, it returns the aqi and if an alert was sent
+func FetchAndAlertAQIForReport(c *config.Config, r config.Report, loc *provider.Location, log *logger.Logger) (int, bool, error) {
+	data, err := FetchLocationAQI(c, loc, log)
+	if err != nil {
+		return -1, false, err
+	}
+	aqi := data.Current.Pollution.AQI
+	log.SyncInfof("AQI: `%d`", aqi)
+
+	store, err := alert.Load(c.GetAlertStateFile())
+	if err != nil {
+		return aqi, false, err
+	}
+	thresholds := alert.NewThresholds(c.GetAlertHysteresis(), c.GetAlertThresholds()...)
+	key := fmt.Sprintf("%s#%s", LocationKey(loc), r.Channel)
+	previous, ok := store.Get(key)
+	if !ok {
+		// state saved before alerts were kept per channel is keyed by the location alone
+		previous, _ = store.Get(LocationKey(loc))
+	}
+	level := thresholds.Level(previous.Level, aqi)
+	store.Set(key, alert.State{Level: level, AQI: aqi, Time: data.Current.Pollution.Time})
+
+	if level == previous.Level {
+		log.SyncInfof("AQI alert level unchanged at `%d`", level)
+		return aqi, false, store.Save()
+	}
+
+	log.SyncInfof("AQI alert level changed from `%d` to `%d`, notifying slack channel `%s`", previous.Level, level, r.Channel)
+	message := AlertSlackMessage(data, LocationName(loc, data), IndexFor(c, "", data), thresholds, previous.Level, level)
+	message.Channel = r.Channel
+	err = slack.Notify(r.Webhook, message)
+	if err != nil {
+		return aqi, false, err
+	}
+	return aqi, true, store.Save()
+}
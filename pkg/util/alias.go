@@ -0,0 +1,90 @@
+package util
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/mat285/aqi/pkg/alias"
+	"github.com/mat285/aqi/pkg/config"
+	"github.com/mat285/slack/slack"
+)
+
+var (
+	aliasLock   sync.Mutex
+	aliasStores = map[string]*alias.Store{}
+)
+
+// AliasStore returns the store of aliases added at runtime for the config, loading it the first time
+// and reloading it whenever another process changed the file
+func AliasStore(c *config.Config) (*alias.Store, error) {
+	aliasLock.Lock()
+	defer aliasLock.Unlock()
+	path := c.GetAliasesFile()
+	if s, ok := aliasStores[path]; ok {
+		return s, s.Refresh()
+	}
+	s, err := alias.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	aliasStores[path] = s
+	return s, nil
+}
+
+// Alias returns the location the alias names, aliases added at runtime take precedence over the config's
+func Alias(c *config.Config, name string) (string, bool) {
+	if s, err := AliasStore(c); err == nil {
+		if location, ok := s.Get(name); ok {
+			return location, true
+		}
+	}
+	return ConfigAlias(c, name)
+}
+
+// ConfigAlias returns the location the alias names in the config file
+func ConfigAlias(c *config.Config, name string) (string, bool) {
+	for n, location := range c.Aliases {
+		if alias.Key(n) == alias.Key(name) {
+			return location, true
+		}
+	}
+	return "", false
+}
+
+// Aliases returns every alias, from the config and added at runtime
+func Aliases(c *config.Config) (map[string]string, error) {
+	s, err := AliasStore(c)
+	if err != nil {
+		return nil, err
+	}
+	ret := map[string]string{}
+	for name, location := range c.Aliases {
+		ret[alias.Key(name)] = location
+	}
+	for name, location := range s.All() {
+		ret[name] = location
+	}
+	return ret, nil
+}
+
+// AliasesSlackMessage returns the ephemeral message listing the aliases
+func AliasesSlackMessage(aliases map[string]string) *slack.Message {
+	text := "There are no aliases yet"
+	if len(aliases) > 0 {
+		names := make([]string, 0, len(aliases))
+		for name := range aliases {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		lines := []string{"Aliases:"}
+		for _, name := range names {
+			lines = append(lines, fmt.Sprintf("`%s` %s", name, aliases[name]))
+		}
+		text = strings.Join(lines, "\n")
+	}
+	m := TextSlackMessage(text)
+	m.ResponseType = slack.ResponseTypeEphemeral
+	return m
+}
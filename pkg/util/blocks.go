@@ -0,0 +1,111 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mat285/aqi/pkg/airvisual"
+	"github.com/mat285/aqi/pkg/epa"
+	"github.com/mat285/aqi/pkg/index"
+	"github.com/mat285/slack/slack"
+)
+
+const (
+	// DefaultHeader is the header used when there is no location name
+	DefaultHeader = "Air Quality"
+	// TimestampFormat is the format of timestamps when slack can't localize them
+	TimestampFormat = "Mon Jan 2 15:04 MST"
+)
+
+var (
+	compassPoints = []string{"N", "NE", "E", "SE", "S", "SW", "W", "NW"}
+)
+
+// ReadingBlocks returns the block kit layout for the reading, a header with the location followed by an attachment
+// colored by the reading's severity with the reading, the guidance if any, the weather in the units, and when it was measured
+func ReadingBlocks(d *airvisual.Data, city string, r *index.Reading, guidance string, units Units) ([]*slack.Block, []*slack.Attachment) {
+	p := d.Current.Pollution
+	headline := fmt.Sprintf("*%s* `%d` %s *%s*", r.Index.Name(), r.Value, EmojiForReading(r), r.Band.Label)
+	if len(r.Pollutant) > 0 {
+		headline = fmt.Sprintf("%s\nMain pollutant: %s", headline, PollutantText(p, r.Pollutant))
+	}
+	blocks := []*slack.Block{slack.NewSectionBlock(headline)}
+	if len(guidance) > 0 {
+		blocks = append(blocks, slack.NewSectionBlock(guidance))
+	}
+	if fields := WeatherFields(d.Current.Weather, units); len(fields) > 0 {
+		blocks = append(blocks, slack.NewFieldsBlock(fields...))
+	}
+	if context := MeasuredText(d, time.Now().UTC()); len(context) > 0 {
+		blocks = append(blocks, slack.NewContextBlock(context))
+	}
+	header := city
+	if len(header) == 0 {
+		header = DefaultHeader
+	}
+	attachment := &slack.Attachment{
+		Color:    SeverityColor(r.Band.Severity),
+		Fallback: fmt.Sprintf("%s %s: %d %s", header, r.Index.Name(), r.Value, r.Band.Label),
+		Blocks:   blocks,
+	}
+	return []*slack.Block{slack.NewHeaderBlock(header)}, []*slack.Attachment{attachment}
+}
+
+// MeasuredText returns when the data was measured and how old the cached copy is if it was fetched a while ago
+func MeasuredText(d *airvisual.Data, now time.Time) string {
+	parts := []string{}
+	if t := d.Current.Pollution.Time; !t.IsZero() {
+		parts = append(parts, TimestampText("Measured", t))
+	}
+	if age := now.Sub(d.Fetched); !d.Fetched.IsZero() && age >= time.Minute {
+		parts = append(parts, fmt.Sprintf("cached %s ago", AgeText(age)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// AgeText returns the duration rounded to minutes, or hours if over an hour
+func AgeText(d time.Duration) string {
+	if d < time.Hour {
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%dh%dm", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// SeverityColor returns the epa color for the severity
+func SeverityColor(severity int) string {
+	if severity < 0 {
+		severity = 0
+	} else if severity >= len(epa.Categories) {
+		severity = len(epa.Categories) - 1
+	}
+	return epa.Categories[severity].Color
+}
+
+// WeatherFields returns the block fields for the weather with temperatures in the units,
+// or nothing if there is no weather data
+func WeatherFields(w airvisual.Weather, units Units) []string {
+	if w.Time.IsZero() && w.Temperature == 0 && w.Humidity == 0 && w.WindSpeed == 0 {
+		return nil
+	}
+	fields := []string{
+		fmt.Sprintf("*Temperature*\n%s", units.Temperature(w.Temperature)),
+		fmt.Sprintf("*Humidity*\n%d%%", w.Humidity),
+		fmt.Sprintf("*Wind*\n%.1f m/s %s", w.WindSpeed, CompassDirection(w.WindDirection)),
+	}
+	if w.Pressure > 0 {
+		fields = append(fields, fmt.Sprintf("*Pressure*\n%d hPa", w.Pressure))
+	}
+	return fields
+}
+
+// CompassDirection returns the compass point for the direction in degrees
+func CompassDirection(degrees int) string {
+	d := ((degrees % 360) + 360) % 360
+	return compassPoints[((d*2+45)/90)%len(compassPoints)]
+}
+
+// TimestampText returns the text for a timestamp that slack shows in the reader's timezone
+func TimestampText(prefix string, t time.Time) string {
+	return fmt.Sprintf("<!date^%d^%s {date_short_pretty} at {time}|%s %s>", t.Unix(), prefix, prefix, t.UTC().Format(TimestampFormat))
+}
@@ -0,0 +1,95 @@
+package util
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	exception "github.com/blend/go-sdk/exception"
+	"github.com/mat285/aqi/pkg/cache"
+	"github.com/mat285/aqi/pkg/config"
+	"github.com/mat285/aqi/pkg/provider"
+	"github.com/mat285/aqi/pkg/quota"
+	"github.com/mat285/slack/slack"
+)
+
+var (
+	cacheLock sync.Mutex
+	caches    = map[string]*cache.Cache{}
+)
+
+// Cache returns the reading cache for the config, loading it with the config's ttl the first time,
+// or nil if the cache is disabled
+func Cache(c *config.Config) (*cache.Cache, error) {
+	ttl := c.GetCacheTTL()
+	if ttl == 0 {
+		return nil, nil
+	}
+	cacheLock.Lock()
+	defer cacheLock.Unlock()
+	if ret, ok := caches[c.CacheFile]; ok {
+		return ret, nil
+	}
+	ret := cache.New(ttl)
+	if len(c.CacheFile) > 0 {
+		loaded, err := cache.Load(c.CacheFile, ttl)
+		if err != nil {
+			return nil, err
+		}
+		ret = loaded
+	}
+	caches[c.CacheFile] = ret
+	return ret, nil
+}
+
+// QuotaSlackMessage returns the ephemeral message for when the airvisual budget is used up,
+// with the last cached reading of the location if there is one
+func QuotaSlackMessage(c *config.Config, class exception.Class, loc *provider.Location, user string) *slack.Message {
+	text := "The AirVisual API budget is used up for this minute, please try again shortly"
+	if class == quota.ErrQuotaExceeded {
+		text = "The AirVisual API budget is used up for this month"
+	}
+	var m *slack.Message
+	if ca, err := Cache(c); err == nil && ca != nil && loc != nil {
+		if e, ok := ca.Last(loc.Key()); ok {
+			data := *e.Data
+			data.Fetched = e.Fetched
+			city := loc.City
+			if len(city) == 0 {
+				city = data.City
+			}
+			m = IndexSlackMessage(&data, city, IndexFor(c, user, &data))
+			text = fmt.Sprintf("%s, this is the last reading from %s ago", text, AgeText(time.Since(e.Fetched)))
+		}
+	}
+	if m == nil {
+		m = TextSlackMessage(text)
+	} else {
+		m.Text = fmt.Sprintf("%s\n%s", text, m.Text)
+		m.Blocks = append([]*slack.Block{slack.NewSectionBlock(text)}, m.Blocks...)
+	}
+	m.ResponseType = slack.ResponseTypeEphemeral
+	return m
+}
+
+// QuotaUsageSlackMessage returns the ephemeral message with how much of the airvisual budgets have been used
+func QuotaUsageSlackMessage(c *config.Config) (*slack.Message, error) {
+	l, err := quota.Shared(c.GetAirVisualQuotaFile(), c.AirVisualRateLimit, c.AirVisualMonthlyQuota, nil)
+	if err != nil {
+		return nil, err
+	}
+	u := l.Current()
+	text := fmt.Sprintf("AirVisual API calls this minute: `%s`\nAirVisual API calls in %s: `%s`",
+		BudgetText(u.MinuteCalls, c.AirVisualRateLimit), u.Month, BudgetText(u.MonthCalls, c.AirVisualMonthlyQuota))
+	m := TextSlackMessage(text)
+	m.ResponseType = slack.ResponseTypeEphemeral
+	return m, nil
+}
+
+// BudgetText returns the calls made out of the budget, or just the calls if there is no budget
+func BudgetText(calls, budget int) string {
+	if budget <= 0 {
+		return fmt.Sprintf("%d", calls)
+	}
+	return fmt.Sprintf("%d/%d", calls, budget)
+}
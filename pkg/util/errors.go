@@ -0,0 +1,45 @@
+package util
+
+import (
+	exception "github.com/blend/go-sdk/exception"
+	"github.com/mat285/aqi/pkg/airvisual"
+	"github.com/mat285/aqi/pkg/config"
+	"github.com/mat285/aqi/pkg/provider"
+	"github.com/mat285/aqi/pkg/quota"
+	"github.com/mat285/slack/slack"
+)
+
+// AirVisualErrorTexts are the replies to airvisual errors users can act on or should know about
+var AirVisualErrorTexts = map[exception.Class]string{
+	airvisual.ErrCityNotFound:        "AirVisual doesn't know that location, use `/aqi locations` to list the supported countries, `/aqi locations \"<Country>\"` for states and `/aqi locations \"<Country>\" \"<State>\"` for cities",
+	airvisual.ErrNoNearestStation:    "There are no AirVisual stations near there, try the city instead",
+	airvisual.ErrIncorrectAPIKey:     "The AirVisual API key is incorrect, please let whoever runs this bot know",
+	airvisual.ErrAPIKeyExpired:       "The AirVisual API key has expired, please let whoever runs this bot know",
+	airvisual.ErrPermissionDenied:    "The AirVisual plan this bot uses doesn't include that, stations, forecasts and pollutant data need a paid plan",
+	airvisual.ErrFeatureNotAvailable: "The AirVisual plan this bot uses doesn't include that, stations, forecasts and pollutant data need a paid plan",
+}
+
+// ErrorSlackMessage returns the ephemeral reply explaining the error if it is one users should see,
+// for used up budgets the reply has the last cached reading of the location if there is a location
+func ErrorSlackMessage(c *config.Config, err error, loc *provider.Location, user string) (*slack.Message, bool) {
+	if class, ok := quota.Exhausted(err); ok {
+		return QuotaSlackMessage(c, class, loc, user), true
+	}
+	class, ok := airvisual.ErrorClass(err)
+	if !ok {
+		return nil, false
+	}
+	switch class {
+	case airvisual.ErrCallLimitReached:
+		return QuotaSlackMessage(c, quota.ErrQuotaExceeded, loc, user), true
+	case airvisual.ErrTooManyRequests:
+		return QuotaSlackMessage(c, quota.ErrRateLimited, loc, user), true
+	}
+	text, ok := AirVisualErrorTexts[class]
+	if !ok {
+		return nil, false
+	}
+	m := TextSlackMessage(text)
+	m.ResponseType = slack.ResponseTypeEphemeral
+	return m, true
+}
@@ -0,0 +1,102 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mat285/aqi/pkg/airvisual"
+	"github.com/mat285/slack/slack"
+)
+
+const (
+	// ForecastHours is how far ahead the hourly forecast is shown
+	ForecastHours = 24 * time.Hour
+	// ForecastHourStep is the spacing between the hourly forecasts shown
+	ForecastHourStep = 3 * time.Hour
+	// ForecastDays is the number of daily forecasts shown
+	ForecastDays = 3
+)
+
+// HourlyForecasts returns the hourly forecasts within the next window after now, spaced by step
+func HourlyForecasts(d *airvisual.Data, now time.Time, window, step time.Duration) []airvisual.Forecast {
+	ret := []airvisual.Forecast{}
+	var last time.Time
+	for _, f := range d.Forecasts {
+		if f.Time.Before(now.Truncate(time.Hour)) || f.Time.After(now.Add(window)) {
+			continue
+		}
+		if !last.IsZero() && f.Time.Sub(last) < step {
+			continue
+		}
+		ret = append(ret, f)
+		last = f.Time
+	}
+	return ret
+}
+
+// DailyForecasts returns up to days daily forecasts starting today,
+// if airvisual did not return daily forecasts the worst hourly forecast of each day is used
+func DailyForecasts(d *airvisual.Data, now time.Time, days int) []airvisual.Forecast {
+	daily := d.DailyForecasts
+	if len(daily) == 0 {
+		daily = worstForecastPerDay(d.Forecasts)
+	}
+	today := now.UTC().Truncate(24 * time.Hour)
+	ret := []airvisual.Forecast{}
+	for _, f := range daily {
+		if len(ret) >= days {
+			break
+		}
+		if f.Time.UTC().Truncate(24 * time.Hour).Before(today) {
+			continue
+		}
+		ret = append(ret, f)
+	}
+	return ret
+}
+
+func worstForecastPerDay(hourly []airvisual.Forecast) []airvisual.Forecast {
+	ret := []airvisual.Forecast{}
+	for _, f := range hourly {
+		day := f.Time.UTC().Truncate(24 * time.Hour)
+		if len(ret) == 0 || !ret[len(ret)-1].Time.Equal(day) {
+			f.Time = day
+			ret = append(ret, f)
+			continue
+		}
+		if f.AQI > ret[len(ret)-1].AQI {
+			f.Time = day
+			ret[len(ret)-1] = f
+		}
+	}
+	return ret
+}
+
+// ForecastSlackMessageText returns the text for a slack message of the forecast
+func ForecastSlackMessageText(d *airvisual.Data, city string, now time.Time) string {
+	hourly := HourlyForecasts(d, now, ForecastHours, ForecastHourStep)
+	daily := DailyForecasts(d, now, ForecastDays)
+	if len(hourly) == 0 && len(daily) == 0 {
+		return fmt.Sprintf("No AQI forecast is available for %s", city)
+	}
+	lines := []string{fmt.Sprintf("%s AQI forecast", city)}
+	if len(hourly) > 0 {
+		lines = append(lines, "Next 24 hours:")
+		for _, f := range hourly {
+			lines = append(lines, fmt.Sprintf("`%s` `%d` %s", f.Time.UTC().Format("15:04 MST"), f.AQI, EmojiForAQI(f.AQI)))
+		}
+	}
+	if len(daily) > 0 {
+		lines = append(lines, fmt.Sprintf("Next %d days:", len(daily)))
+		for _, f := range daily {
+			lines = append(lines, fmt.Sprintf("`%s` `%d` %s", f.Time.UTC().Format("Mon Jan 2"), f.AQI, EmojiForAQI(f.AQI)))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ForecastSlackMessage returns the message to send back for the forecast to slack
+func ForecastSlackMessage(d *airvisual.Data, city string) *slack.Message {
+	return TextSlackMessage(ForecastSlackMessageText(d, city, time.Now().UTC()))
+}
@@ -0,0 +1,203 @@
+package util
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	logger "github.com/blend/go-sdk/logger"
+	"github.com/mat285/aqi/pkg/airvisual"
+	"github.com/mat285/aqi/pkg/chart"
+	"github.com/mat285/aqi/pkg/config"
+	"github.com/mat285/aqi/pkg/history"
+	"github.com/mat285/slack/slack"
+)
+
+const (
+	// HistoryChartPath is the path the server serves history charts on
+	HistoryChartPath = "/chart"
+	// HistoryChartURLTTL is how long a signed chart url can be fetched for
+	HistoryChartURLTTL = 7 * 24 * time.Hour
+)
+
+var (
+	historyLock   sync.Mutex
+	historyStores = map[string]*history.Store{}
+)
+
+// HistoryStore returns the history store for the config, opening it the first time. When the default
+// database is created, the readings of the json lines file history used to be kept in are imported
+func HistoryStore(c *config.Config) (*history.Store, error) {
+	historyLock.Lock()
+	defer historyLock.Unlock()
+	path := c.GetHistoryFile()
+	if s, ok := historyStores[path]; ok {
+		return s, nil
+	}
+	_, err := os.Stat(path)
+	created := os.IsNotExist(err)
+	s, err := history.Open(path, c.GetHistoryRetention())
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(config.LegacyHistoryFile); created && len(c.HistoryFile) == 0 && err == nil {
+		err = s.Import(config.LegacyHistoryFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+	historyStores[path] = s
+	return s, nil
+}
+
+// RecordReading records the air data to the history store, failures are logged rather than returned
+// so that history never gets in the way of a reply
+func RecordReading(c *config.Config, d *airvisual.Data, log *logger.Logger) {
+	s, err := HistoryStore(c)
+	if err != nil {
+		log.SyncError(err)
+		return
+	}
+	r := history.NewReading(d)
+	recorded, err := s.Record(r)
+	if err != nil {
+		log.SyncError(err)
+		return
+	}
+	if recorded {
+		log.SyncInfof("Recorded AQI `%d` for `%s` at `%s`", r.AQI, r.Location, r.Time)
+	}
+}
+
+// FetchHistory summarizes the recorded readings of the location over the period up to now
+func FetchHistory(c *config.Config, req *airvisual.LocationRequest, period time.Duration) (*history.Summary, error) {
+	s, err := HistoryStore(c)
+	if err != nil {
+		return nil, err
+	}
+	return s.Summarize(s.Resolve(req.City, req.State, req.Country), period, time.Now().UTC())
+}
+
+// HistorySlackMessageText returns the text for a slack message of the history summary
+func HistorySlackMessageText(s *history.Summary, city, period string) string {
+	if s.Count == 0 {
+		return fmt.Sprintf("No AQI readings have been recorded for %s in the last %s", city, period)
+	}
+	lines := []string{
+		fmt.Sprintf("%s AQI over the last %s from %d readings", city, period, s.Count),
+		fmt.Sprintf("Min: `%d` %s Max: `%d` %s Mean: `%.0f` %s", s.Min, EmojiForAQI(s.Min), s.Max, EmojiForAQI(s.Max), s.Mean, EmojiForAQI(int(s.Mean+0.5))),
+	}
+	for _, c := range s.Categories {
+		lines = append(lines, fmt.Sprintf("%s %s: `%s`", EmojiForSeverity(c.Category.Severity()), c.Category.Name, HoursText(c.Duration)))
+	}
+	switch s.Trend {
+	case history.TrendUnknown:
+		lines = append(lines, fmt.Sprintf("Trend: no readings from the prior %s to compare to", period))
+	default:
+		lines = append(lines, fmt.Sprintf("Trend: %s %s from a mean of `%.0f` the prior %s", TrendEmoji(s.Trend), s.Trend, s.Previous, period))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// HoursText returns the duration in hours to a tenth of an hour
+func HoursText(d time.Duration) string {
+	hours := math.Round(d.Hours()*10) / 10
+	if hours == 1 {
+		return "1 hour"
+	}
+	return fmt.Sprintf("%s hours", strconv.FormatFloat(hours, 'f', -1, 64))
+}
+
+// TrendEmoji returns the emoji for the trend
+func TrendEmoji(t history.Trend) string {
+	switch t {
+	case history.TrendImproving:
+		return ":arrow_down:"
+	case history.TrendWorsening:
+		return ":arrow_up:"
+	}
+	return ":left_right_arrow:"
+}
+
+// HistorySlackMessage returns the message to send back for the history summary to slack,
+// with a chart of the readings if there is a chart url
+func HistorySlackMessage(s *history.Summary, city, period, chartURL string) *slack.Message {
+	text := HistorySlackMessageText(s, city, period)
+	m := TextSlackMessage(text)
+	if s.Count > 0 && len(chartURL) > 0 {
+		m.Blocks = []*slack.Block{
+			slack.NewSectionBlock(text),
+			slack.NewImageBlock(chartURL, fmt.Sprintf("%s AQI over the last %s", city, period), ""),
+		}
+	}
+	return m
+}
+
+// HistoryChart renders a png chart of the recorded readings of the location over the period up to now
+func HistoryChart(c *config.Config, location string, period time.Duration) ([]byte, error) {
+	s, err := HistoryStore(c)
+	if err != nil {
+		return nil, err
+	}
+	until := time.Now().UTC()
+	since := until.Add(-period)
+	readings, err := s.Query(location, since, until)
+	if err != nil {
+		return nil, err
+	}
+	points := make([]chart.Point, len(readings))
+	for i, r := range readings {
+		points[i] = chart.Point{Time: r.Time, AQI: r.AQI}
+	}
+	buf := &bytes.Buffer{}
+	err = chart.New(since, until, points).Render(buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// HistoryChartURL returns the url of the chart endpoint for the location and period signed with the secret,
+// the time busts slack's image cache so the chart is redrawn on every request
+func HistoryChartURL(secret, baseURL, location, period string, now time.Time) string {
+	t := strconv.FormatInt(now.Unix(), 10)
+	values := url.Values{}
+	values.Set("location", location)
+	values.Set("period", period)
+	values.Set("t", t)
+	values.Set("sig", HistoryChartSignature(secret, location, period, t))
+	return fmt.Sprintf("%s%s?%s", strings.TrimSuffix(baseURL, "/"), HistoryChartPath, values.Encode())
+}
+
+// HistoryChartSignature returns the hex hmac sha256 of the chart url's location, period and time with the secret
+func HistoryChartSignature(secret, location, period, t string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strings.Join([]string{location, period, t}, "\n")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyHistoryChartURL returns if the chart url's signature matches the secret and it was signed
+// within the last HistoryChartURLTTL
+func VerifyHistoryChartURL(secret, location, period, t, sig string, now time.Time) bool {
+	if len(secret) == 0 {
+		return false
+	}
+	unix, err := strconv.ParseInt(t, 10, 64)
+	if err != nil {
+		return false
+	}
+	signed := time.Unix(unix, 0)
+	if now.Sub(signed) > HistoryChartURLTTL || signed.After(now.Add(time.Minute)) {
+		return false
+	}
+	return hmac.Equal([]byte(sig), []byte(HistoryChartSignature(secret, location, period, t)))
+}
@@ -0,0 +1,101 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/mat285/aqi/pkg/config"
+	"github.com/mat285/aqi/pkg/prefs"
+	"github.com/mat285/slack/slack"
+)
+
+// Units are the units the weather is shown in
+type Units string
+
+const (
+	// Celsius shows temperatures in degrees celsius
+	Celsius Units = "c"
+	// Fahrenheit shows temperatures in degrees fahrenheit
+	Fahrenheit Units = "f"
+)
+
+// ParseUnits parses the units, by their letter or name
+func ParseUnits(text string) (Units, bool) {
+	switch strings.ToLower(strings.TrimSpace(text)) {
+	case "c", "celsius", "metric":
+		return Celsius, true
+	case "f", "fahrenheit", "imperial":
+		return Fahrenheit, true
+	}
+	return "", false
+}
+
+// Temperature returns the text for the temperature in celsius in the units
+func (u Units) Temperature(celsius int) string {
+	if u == Fahrenheit {
+		return fmt.Sprintf("%.0f°F", float64(celsius)*9/5+32)
+	}
+	return fmt.Sprintf("%d°C", celsius)
+}
+
+var (
+	prefsLock   sync.Mutex
+	prefsStores = map[string]*prefs.Store{}
+)
+
+// PreferenceStore returns the store of user preferences for the config, loading it the first time
+func PreferenceStore(c *config.Config) (*prefs.Store, error) {
+	prefsLock.Lock()
+	defer prefsLock.Unlock()
+	path := c.GetPreferencesFile()
+	if s, ok := prefsStores[path]; ok {
+		return s, nil
+	}
+	s, err := prefs.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	prefsStores[path] = s
+	return s, nil
+}
+
+// UserPreferences returns the preferences of the slack user, none if the store can't be loaded
+func UserPreferences(c *config.Config, user string) prefs.Preferences {
+	if len(user) == 0 {
+		return prefs.Preferences{}
+	}
+	s, err := PreferenceStore(c)
+	if err != nil {
+		return prefs.Preferences{}
+	}
+	return s.Get(user)
+}
+
+// UserUnits returns the units the slack user sees the weather in, celsius by default
+func UserUnits(c *config.Config, user string) Units {
+	if u, ok := ParseUnits(UserPreferences(c, user).Units); ok {
+		return u
+	}
+	return Celsius
+}
+
+// PreferencesSlackMessage returns the ephemeral message listing the user's preferences
+func PreferencesSlackMessage(p prefs.Preferences) *slack.Message {
+	location, idx, units, replies := "not set", "not set", "`c`", "in the channel"
+	if len(p.Location) > 0 {
+		location = fmt.Sprintf("`%s`", p.Location)
+	}
+	if len(p.Index) > 0 {
+		idx = fmt.Sprintf("`%s`", p.Index)
+	}
+	if len(p.Units) > 0 {
+		units = fmt.Sprintf("`%s`", p.Units)
+	}
+	if p.Private {
+		replies = "only to you"
+	}
+	m := TextSlackMessage(fmt.Sprintf("Your preferences:\nLocation: %s\nIndex: %s\nUnits: %s\nReplies: %s", location, idx, units, replies))
+	m.ResponseType = slack.ResponseTypeEphemeral
+	return m
+}
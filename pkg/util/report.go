@@ -0,0 +1,105 @@
+package util
+
+import (
+	"bytes"
+	"text/template"
+	"time"
+
+	exception "github.com/blend/go-sdk/exception"
+	logger "github.com/blend/go-sdk/logger"
+	"github.com/mat285/aqi/pkg/airvisual"
+	"github.com/mat285/aqi/pkg/config"
+	"github.com/mat285/aqi/pkg/epa"
+	"github.com/mat285/aqi/pkg/index"
+	"github.com/mat285/slack/slack"
+)
+
+// ReportTemplateData is the data a report's message template is executed with
+type ReportTemplateData struct {
+	Location  string
+	AQI       int
+	Category  string
+	Emoji     string
+	Index     string
+	Value     int
+	Band      string
+	Pollutant string
+	Time      time.Time
+	Data      *airvisual.Data
+}
+
+// DefaultReport returns the report to the config's webhook and channel
+func DefaultReport(c *config.Config) config.Report {
+	return config.Report{
+		Webhook: c.SlackWebhook,
+		Channel: c.GetSlackChannel(config.DefaultSlackChannel),
+	}
+}
+
+// SendReport fetches the aqi for each of the report's locations and sends it to the report's channel,
+// in alert mode each location is only sent if it crossed an alert threshold
+func SendReport(c *config.Config, r config.Report, log *logger.Logger) error {
+	var last error
+	for _, location := range r.GetLocations() {
+		err := sendReportLocation(c, r, location, log)
+		if err != nil {
+			log.SyncError(err)
+			last = err
+		}
+	}
+	return last
+}
+
+func sendReportLocation(c *config.Config, r config.Report, location string, log *logger.Logger) error {
+	loc, places := ResolveLocation(c, location)
+	if len(places) > 0 {
+		return exception.New("AmbiguousReportLocation").WithMessagef("%s could be %s", location, places)
+	}
+	if loc == nil {
+		return exception.New("InvalidReportLocation").WithMessage(location)
+	}
+	if c.AlertMode {
+		_, _, err := FetchAndAlertAQIForReport(c, r, loc, log)
+		return err
+	}
+	_, err := FetchAndSendAQIForReport(c, r, loc, log)
+	return err
+}
+
+// ReportSlackMessage returns the message for the report, rendering its template if it has one
+func ReportSlackMessage(r config.Report, d *airvisual.Data, city string, idx index.Index) (*slack.Message, error) {
+	if len(r.Template) == 0 {
+		return IndexSlackMessage(d, city, idx), nil
+	}
+	t, err := template.New(r.GetName()).Parse(r.Template)
+	if err != nil {
+		return nil, exception.New(err)
+	}
+	buf := &bytes.Buffer{}
+	err = t.Execute(buf, NewReportTemplateData(d, city, idx))
+	if err != nil {
+		return nil, exception.New(err)
+	}
+	return TextSlackMessage(buf.String()), nil
+}
+
+// NewReportTemplateData returns the template data for the air data on the index
+func NewReportTemplateData(d *airvisual.Data, city string, idx index.Index) *ReportTemplateData {
+	p := d.Current.Pollution
+	r, ok := index.Compute(idx.Resolve(d.Country), p)
+	if !ok {
+		r = USReading(p)
+	}
+	return &ReportTemplateData{
+		Location:  city,
+		AQI:       p.AQI,
+		Category:  epa.CategoryForAQI(p.AQI).Name,
+		Emoji:     EmojiForReading(r),
+		Index:     r.Index.Name(),
+		Value:     r.Value,
+		Band:      r.Band.Label,
+		Pollutant: r.Pollutant.Name(),
+		Time:      p.Time,
+		Data:      d,
+	}
+}
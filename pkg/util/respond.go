@@ -0,0 +1,40 @@
+package util
+
+import (
+	"time"
+
+	logger "github.com/blend/go-sdk/logger"
+	"github.com/mat285/slack/slack"
+)
+
+const (
+	// RespondAttempts is how many times posting a reply to a response url is tried
+	RespondAttempts = 3
+	// RespondBackoff is the wait before the first retry, doubling after each attempt
+	RespondBackoff = time.Second
+	// AcknowledgeText is the text of the reply sent right away for commands answered asynchronously
+	AcknowledgeText = "Fetching air quality data…"
+)
+
+// AcknowledgeSlackMessage returns the ephemeral reply sent right away for commands answered asynchronously
+func AcknowledgeSlackMessage() *slack.Message {
+	m := TextSlackMessage(AcknowledgeText)
+	m.ResponseType = slack.ResponseTypeEphemeral
+	return m
+}
+
+// Respond posts the message to the slash command's response url, retrying with backoff if it fails
+func Respond(responseURL string, m *slack.Message, log *logger.Logger) error {
+	var err error
+	for attempt := 1; attempt <= RespondAttempts; attempt++ {
+		if attempt > 1 {
+			time.Sleep(RespondBackoff << uint(attempt-2))
+		}
+		err = slack.Notify(responseURL, m)
+		if err == nil {
+			return nil
+		}
+		log.SyncWarningf("Posting the response failed on attempt %d of %d: %v", attempt, RespondAttempts, err)
+	}
+	return err
+}
@@ -3,13 +3,19 @@ package util
 import (
 	"fmt"
 	"io/ioutil"
+	"strconv"
 	"strings"
 
 	exception "github.com/blend/go-sdk/exception"
 	logger "github.com/blend/go-sdk/logger"
 	util "github.com/blendlabs/go-util"
 	"github.com/mat285/aqi/pkg/airvisual"
+	"github.com/mat285/aqi/pkg/command"
 	"github.com/mat285/aqi/pkg/config"
+	"github.com/mat285/aqi/pkg/epa"
+	"github.com/mat285/aqi/pkg/gazetteer"
+	"github.com/mat285/aqi/pkg/index"
+	"github.com/mat285/aqi/pkg/provider"
 	"github.com/mat285/slack/slack"
 )
 
@@ -20,21 +26,37 @@ const (
 	SlackEmoji = ":cloud:"
 	// HealthyEmoji is the healthy emoji
 	HealthyEmoji = ":slightly_smiling_face:"
+	// ModerateEmoji is the moderate emoji
+	ModerateEmoji = ":neutral_face:"
+	// SensitiveEmoji is the unhealthy for sensitive groups emoji
+	SensitiveEmoji = ":warning:"
 	// UnhealthyEmoji is the unhealthy emoji
 	UnhealthyEmoji = ":mask:"
+	// VeryUnhealthyEmoji is the very unhealthy emoji
+	VeryUnhealthyEmoji = ":biohazard_sign:"
 	// ToxicEmoji is the toxic emoji
 	ToxicEmoji = ":skull_and_crossbones:"
 
 	// CigarettesPerAQI is the number of cigarettes per point of aqi
 	CigarettesPerAQI = 0.04631
 
-	// CountryCodeUSA is the country code for USA
-	CountryCodeUSA = "USA"
-	// StateCodeCalifornia is the state code for california
-	StateCodeCalifornia = "California"
+	// DefaultLocation is the location used when none is given
+	DefaultLocation = "sf"
+	// MaxAmbiguousPlaces is the most places listed when a location could be more than one
+	MaxAmbiguousPlaces = 10
 )
 
 var (
+	// SeverityEmojis are the emojis for each severity from good through hazardous
+	SeverityEmojis = []string{
+		HealthyEmoji,
+		ModerateEmoji,
+		SensitiveEmoji,
+		UnhealthyEmoji,
+		VeryUnhealthyEmoji,
+		ToxicEmoji,
+	}
+
 	// BlockedUsers are the users who need to ask nicely
 	BlockedUsers = map[string]bool{}
 )
@@ -67,43 +89,155 @@ func NumCigarettes(aqi int) float32 {
 	return float32(aqi) * CigarettesPerAQI
 }
 
-// EmojiForAQI returns the appropriate emohi for the aqi
+// EmojiForAQI returns the appropriate emoji for the aqi
 func EmojiForAQI(aqi int) string {
-	if aqi <= 50 {
-		return HealthyEmoji
-	} else if aqi <= 200 {
-		return UnhealthyEmoji
-	} else {
-		return ToxicEmoji
+	return EmojiForSeverity(epa.CategoryForAQI(aqi).Severity())
+}
+
+// EmojiForSeverity returns the appropriate emoji for the severity
+func EmojiForSeverity(severity int) string {
+	if severity < 0 {
+		return SeverityEmojis[0]
+	} else if severity >= len(SeverityEmojis) {
+		return SeverityEmojis[len(SeverityEmojis)-1]
 	}
+	return SeverityEmojis[severity]
 }
 
 // SlackMessageText returns the text for a slack message of the aqi
-func SlackMessageText(aqi int, city string) string {
-	return fmt.Sprintf("%s current AQI: `%d` %s", city, aqi, EmojiForAQI(aqi))
+func SlackMessageText(p airvisual.Pollution, city string) string {
+	category := epa.CategoryForAQI(p.AQI)
+	text := fmt.Sprintf("%s current AQI: `%d` %s %s", city, p.AQI, EmojiForAQI(p.AQI), category.Name)
+	if len(p.MainPollutant) > 0 {
+		text = fmt.Sprintf("%s, main pollutant: %s", text, PollutantText(p, p.MainPollutant))
+	}
+	return text
+}
+
+// EmojiForReading returns the appropriate emoji for the reading on any index
+func EmojiForReading(r *index.Reading) string {
+	return EmojiForSeverity(r.Band.Severity)
 }
 
-// LocationRequestFromText returns the location request from the text
-func LocationRequestFromText(text string) *airvisual.LocationRequest {
-	text = strings.TrimSpace(strings.ToLower(text))
-	if strings.HasPrefix(text, "city ") {
-		return CityAirVisualRequest(text)
-	} else if strings.Contains(text, "sf") || strings.Contains(text, "san francisco") {
-		return SanFranciscoAirVisualRequest()
-	} else if strings.Contains(text, "nyc") || strings.Contains(text, "new york") {
-		return NewYorkAirVisualRequest()
-	} else if strings.Contains(text, "seattle") {
-		return SeattleAirVisualRequest()
-	} else if strings.Contains(text, " la ") || strings.TrimSpace(text) == "la" || strings.Contains(text, "los angeles") {
-		return LosAngelesAirVisualRequest()
+// GuidanceText returns the health guidance for the category
+func GuidanceText(c epa.Category) string {
+	text := fmt.Sprintf("> %s", c.HealthImplications)
+	if c.Severity() > 0 {
+		text = fmt.Sprintf("%s\n> *Sensitive groups:* %s", text, c.SensitiveGroups)
+	}
+	return text
+}
+
+// ReadingSlackMessageText returns the text for a slack message of the reading on an index
+func ReadingSlackMessageText(r *index.Reading, p airvisual.Pollution, city string) string {
+	text := fmt.Sprintf("%s current %s: `%d` %s (%s)", city, r.Index.Name(), r.Value, EmojiForReading(r), r.Band.Label)
+	if len(r.Pollutant) > 0 {
+		text = fmt.Sprintf("%s, main pollutant: %s", text, PollutantText(p, r.Pollutant))
+	}
+	return text
+}
+
+// PollutantText returns the text for a pollutant including its concentration if known
+func PollutantText(p airvisual.Pollution, pollutant airvisual.Pollutant) string {
+	conc := p.Concentration(pollutant)
+	if conc == nil {
+		return pollutant.Name()
+	}
+	return fmt.Sprintf("%s (`%v` %s)", pollutant.Name(), conc.Value, PollutantUnits(pollutant))
+}
+
+// PollutantUnits returns the units airvisual reports the pollutant concentration in
+func PollutantUnits(pollutant airvisual.Pollutant) string {
+	switch pollutant {
+	case airvisual.PollutantPM25, airvisual.PollutantPM10:
+		return "ug/m3"
+	case airvisual.PollutantCO:
+		return "ppm"
+	}
+	return "ppb"
+}
+
+// LocationRequestFromText returns the location request from the text, the default location if the text is empty,
+// or nil if the text is not a known city or could be more than one
+func LocationRequestFromText(c *config.Config, text string) *airvisual.LocationRequest {
+	loc, _ := ResolveLocation(c, text)
+	if !loc.HasCity() {
+		return nil
+	}
+	return loc.LocationRequest()
+}
+
+// ResolveLocation returns the location the text names, the default location if the text is empty,
+// looking up aliases before resolving the location spec. If the text could be more than one place
+// it returns them instead, and if it is not a known location it returns neither
+func ResolveLocation(c *config.Config, text string) (*provider.Location, []gazetteer.Place) {
+	text = strings.Join(strings.Fields(text), " ")
+	if strings.HasPrefix(strings.ToLower(text), "in ") {
+		text = text[len("in "):]
+	}
+	if len(text) == 0 {
+		text = DefaultLocation
+	}
+	if location, ok := Alias(c, text); ok {
+		text = location
+	}
+	return ResolveLocationSpec(text)
+}
+
+// ResolveLocationSpec returns the location the spec names without looking up aliases, coordinates,
+// a `city` request, or a place in the gazetteer by name or zip code, or the places it could be if there are several
+func ResolveLocationSpec(text string) (*provider.Location, []gazetteer.Place) {
+	if lat, lon, ok := CoordinatesFromText(text); ok {
+		return provider.CoordinatesLocation(lat, lon), nil
+	}
+	if strings.HasPrefix(strings.ToLower(text), "city ") {
+		req := CityAirVisualRequest(strings.ToLower(text))
+		if req == nil {
+			return nil, nil
+		}
+		return provider.CityLocation(req), nil
+	}
+	places := gazetteer.Search(text)
+	if len(places) != 1 {
+		return nil, places
 	}
-	return SanFranciscoAirVisualRequest()
+	return PlaceLocation(places[0]), nil
+}
+
+// PlaceLocation returns the location of the gazetteer place, with its coordinates for providers that need them
+func PlaceLocation(p gazetteer.Place) *provider.Location {
+	return &provider.Location{
+		City:        p.City,
+		State:       p.Region,
+		Country:     p.Country,
+		Coordinates: &provider.Coordinates{Latitude: p.Latitude, Longitude: p.Longitude},
+	}
+}
+
+// CoordinatesFromText parses a `<lat>,<lon>` or `<lat> <lon>` pair from the text
+func CoordinatesFromText(text string) (float64, float64, bool) {
+	parts := strings.Fields(strings.Replace(strings.TrimSpace(text), ",", " ", -1))
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+	lat, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	lon, err := strconv.ParseFloat(parts[1], 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	if airvisual.ValidateCoordinates(lat, lon) != nil {
+		return 0, 0, false
+	}
+	return lat, lon, true
 }
 
 // CityAirVisualRequest returns the request for a city
 func CityAirVisualRequest(text string) *airvisual.LocationRequest {
 	text = strings.TrimSpace(strings.Trim(text, "city"))
-	parts := SplitOnSpacePreserveQuotes(text)
+	parts := command.Tokenize(text)
 	logger.All().Debugf("Parsed Input: %v", parts)
 	if len(parts) < 3 {
 		return nil
@@ -115,91 +249,251 @@ func CityAirVisualRequest(text string) *airvisual.LocationRequest {
 	}
 }
 
-// SanFranciscoAirVisualRequest returns the request for sf
-func SanFranciscoAirVisualRequest() *airvisual.LocationRequest {
-	return &airvisual.LocationRequest{
-		City:    "San Francisco",
-		State:   StateCodeCalifornia,
-		Country: CountryCodeUSA,
+// StationAirVisualRequest returns the request for a station
+func StationAirVisualRequest(text string) *airvisual.StationRequest {
+	text = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(text), "station"))
+	parts := command.Tokenize(text)
+	logger.All().Debugf("Parsed Input: %v", parts)
+	if len(parts) < 4 {
+		return nil
+	}
+	return &airvisual.StationRequest{
+		Station: util.String.ToTitleCase(parts[0]),
+		City:    util.String.ToTitleCase(parts[1]),
+		State:   util.String.ToTitleCase(parts[2]),
+		Country: util.String.ToTitleCase(parts[3]),
 	}
 }
 
-// NewYorkAirVisualRequest returns the request for nyc
-func NewYorkAirVisualRequest() *airvisual.LocationRequest {
-	return &airvisual.LocationRequest{
-		City:    "New York",
-		State:   "New York",
-		Country: CountryCodeUSA,
+// TextSlackMessage returns a plain text message from the bot
+func TextSlackMessage(text string) *slack.Message {
+	return &slack.Message{
+		Username:     SlackUsername,
+		Text:         text,
+		IconEmoji:    SlackEmoji,
+		ResponseType: slack.ResponseTypeInChannel,
 	}
 }
 
-// LosAngelesAirVisualRequest returns the request for la
-func LosAngelesAirVisualRequest() *airvisual.LocationRequest {
-	return &airvisual.LocationRequest{
-		City:    "Los Angeles",
-		State:   StateCodeCalifornia,
-		Country: CountryCodeUSA,
+// BlockedSlackMessage returns the message to a blocked user
+func BlockedSlackMessage() *slack.Message {
+	return TextSlackMessage("no")
+}
+
+// CigarettesSlackMessage returns the message for cigarettes
+func CigarettesSlackMessage(d *airvisual.Data, city string) *slack.Message {
+	return TextSlackMessage(fmt.Sprintf("%s number of cigarettes: `%03f`", city, NumCigarettes(d.Current.Pollution.AQI)))
+}
+
+// UnknownLocationSlackMessage returns the message for a location that could not be understood
+func UnknownLocationSlackMessage(text string) *slack.Message {
+	m := TextSlackMessage(fmt.Sprintf("I don't know the location `%s`, try a city like `portland oregon`, a zip code, `city \"<City>\" \"<State>\" \"<Country>\"` or `<lat>,<lon>`, or `/aqi help` for everything I can do", text))
+	m.ResponseType = slack.ResponseTypeEphemeral
+	return m
+}
+
+// AmbiguousLocationSlackMessage returns the message asking which of the places the text meant,
+// with the command to run for each
+func AmbiguousLocationSlackMessage(text string, places []gazetteer.Place, command string) *slack.Message {
+	lines := []string{fmt.Sprintf("`%s` could be more than one place, which did you mean?", text)}
+	for i, p := range places {
+		if i == MaxAmbiguousPlaces {
+			lines = append(lines, fmt.Sprintf("and %d more, add the state or country to narrow it down", len(places)-i))
+			break
+		}
+		lines = append(lines, fmt.Sprintf("`%s %s` for %s", command, gazetteer.Qualify(p, places), p))
 	}
+	m := TextSlackMessage(strings.Join(lines, "\n"))
+	m.ResponseType = slack.ResponseTypeEphemeral
+	return m
 }
 
-// SeattleAirVisualRequest returns the request for seattle
-func SeattleAirVisualRequest() *airvisual.LocationRequest {
-	return &airvisual.LocationRequest{
-		City:    "Seattle",
-		State:   "Washington",
-		Country: CountryCodeUSA,
+// LocationsSlackMessage returns the message listing the supported locations
+func LocationsSlackMessage(country, state string, names []string) *slack.Message {
+	title := "Supported countries"
+	if len(state) > 0 {
+		title = fmt.Sprintf("Supported cities in %s, %s", state, country)
+	} else if len(country) > 0 {
+		title = fmt.Sprintf("Supported states in %s", country)
+	}
+	text := fmt.Sprintf("%s:\n%s", title, strings.Join(names, "\n"))
+	if len(names) == 0 {
+		text = fmt.Sprintf("%s: none found", title)
 	}
+	m := TextSlackMessage(text)
+	m.ResponseType = slack.ResponseTypeEphemeral
+	return m
 }
 
-// BlockedSlackMessage returns the message to a blocked user
-func BlockedSlackMessage() *slack.Message {
-	m := AQISlackMessage(-1, "")
-	m.Text = "no"
+// AQISlackMessage returns the message to send back for the aqi to slack with the health guidance for its category
+func AQISlackMessage(d *airvisual.Data, city string) *slack.Message {
+	return aqiSlackMessage(d, city, Celsius)
+}
+
+func aqiSlackMessage(d *airvisual.Data, city string, units Units) *slack.Message {
+	p := d.Current.Pollution
+	guidance := GuidanceText(epa.CategoryForAQI(p.AQI))
+	m := TextSlackMessage(fmt.Sprintf("%s\n%s", SlackMessageText(p, city), guidance))
+	m.Blocks, m.Attachments = ReadingBlocks(d, city, USReading(p), guidance, units)
 	return m
 }
 
-// CigarettesSlackMessage returns the message for cigarettes
-func CigarettesSlackMessage(aqi int, city string) *slack.Message {
-	m := AQISlackMessage(aqi, city)
-	m.Text = fmt.Sprintf("%s number of cigarettes: `%03f`", city, NumCigarettes(aqi))
+// IndexSlackMessage returns the message to send back for the aqi on the index to slack,
+// falling back to the us aqi if the index cannot be computed from the data
+func IndexSlackMessage(d *airvisual.Data, city string, idx index.Index) *slack.Message {
+	return UnitsIndexSlackMessage(d, city, idx, Celsius)
+}
+
+// UnitsIndexSlackMessage returns the message for the aqi on the index with the weather in the units
+func UnitsIndexSlackMessage(d *airvisual.Data, city string, idx index.Index, units Units) *slack.Message {
+	idx = idx.Resolve(d.Country)
+	if idx == index.US {
+		return aqiSlackMessage(d, city, units)
+	}
+	r, ok := index.Compute(idx, d.Current.Pollution)
+	if !ok {
+		m := aqiSlackMessage(d, city, units)
+		note := fmt.Sprintf("_%s is not available for %s_", idx.Name(), city)
+		m.Text = fmt.Sprintf("%s\n%s", m.Text, note)
+		m.Blocks = append(m.Blocks, slack.NewContextBlock(note))
+		return m
+	}
+	m := TextSlackMessage(ReadingSlackMessageText(r, d.Current.Pollution, city))
+	m.Blocks, m.Attachments = ReadingBlocks(d, city, r, "", units)
 	return m
 }
 
-// AQISlackMessage returns the message to send back for the aqi to slack
-func AQISlackMessage(aqi int, city string) *slack.Message {
-	return &slack.Message{
-		Username:     SlackUsername,
-		Text:         SlackMessageText(aqi, city),
-		IconEmoji:    SlackEmoji,
-		ResponseType: slack.ResponseTypeInChannel,
+// USReading returns the us aqi reading airvisual reported for the pollution
+func USReading(p airvisual.Pollution) *index.Reading {
+	return &index.Reading{
+		Index:     index.US,
+		Value:     p.AQI,
+		Pollutant: p.MainPollutant,
+		Band:      index.US.Band(p.AQI),
+	}
+}
+
+// IndexFor returns the index to report the data in for the user, the index the user prefers if they set one
+func IndexFor(c *config.Config, user string, d *airvisual.Data) index.Index {
+	if i, ok := index.Parse(UserPreferences(c, user).Index); ok {
+		return i.Resolve(d.Country)
 	}
+	i, ok := index.Parse(c.GetIndex(user, d.City, d.Country))
+	if !ok {
+		return index.US
+	}
+	return i.Resolve(d.Country)
 }
 
-// FetchAQI fetches the aqi from airvisual
-func FetchAQI(c *config.Config, req *airvisual.LocationRequest, log *logger.Logger) (int, error) {
-	client := airvisual.New(c.AirVisualAPIKey)
-	log.SyncInfof("Sending request for air data")
-	resp, err := client.Location(req)
+// FetchAQI fetches the air data from the configured providers
+func FetchAQI(c *config.Config, req *airvisual.LocationRequest, log *logger.Logger) (*airvisual.Data, error) {
+	return fetchFromProviders(c, provider.CityLocation(req), log)
+}
+
+// FetchLocationAQI fetches the air data for the location from the configured providers
+func FetchLocationAQI(c *config.Config, loc *provider.Location, log *logger.Logger) (*airvisual.Data, error) {
+	return fetchFromProviders(c, loc, log)
+}
+
+// FetchNearestCityAQI fetches the air data nearest the coordinates from the configured providers
+func FetchNearestCityAQI(c *config.Config, lat, lon float64, log *logger.Logger) (*airvisual.Data, error) {
+	return fetchFromProviders(c, provider.CoordinatesLocation(lat, lon), log)
+}
+
+func fetchFromProviders(c *config.Config, loc *provider.Location, log *logger.Logger) (*airvisual.Data, error) {
+	p, err := provider.NewFromConfig(c, log)
 	if err != nil {
-		return -1, err
+		return nil, err
+	}
+	ca, err := Cache(c)
+	if err != nil {
+		return nil, err
+	}
+	if ca != nil {
+		p = &provider.Cached{Provider: p, Cache: ca, Log: log}
+	}
+	log.SyncInfof("Sending request for air data for `%s` to `%s`", loc, p.Name())
+	data, err := p.Current(loc)
+	if err != nil {
+		return nil, err
+	}
+	RecordReading(c, data, log)
+	return data, nil
+}
+
+// FetchStationAQI fetches the air data for the station from airvisual
+func FetchStationAQI(c *config.Config, req *airvisual.StationRequest, log *logger.Logger) (*airvisual.Data, error) {
+	client, err := provider.AirVisualClient(c, log)
+	if err != nil {
+		return nil, err
+	}
+	log.SyncInfof("Sending request for station air data")
+	return responseData(client.Station(req))
+}
+
+// FetchNearestStationAQI fetches the air data for the station nearest the coordinates from airvisual
+func FetchNearestStationAQI(c *config.Config, lat, lon float64, log *logger.Logger) (*airvisual.Data, error) {
+	client, err := provider.AirVisualClient(c, log)
+	if err != nil {
+		return nil, err
+	}
+	log.SyncInfof("Sending request for station air data near %v,%v", lat, lon)
+	return responseData(client.NearestStation(lat, lon))
+}
+
+// FetchLocations fetches the supported countries, the states in a country, or the cities in a state from airvisual
+func FetchLocations(c *config.Config, country, state string, log *logger.Logger) ([]string, error) {
+	client, err := provider.AirVisualClient(c, log)
+	if err != nil {
+		return nil, err
+	}
+	log.SyncInfof("Sending request for supported locations")
+	var resp *airvisual.ListResponse
+	if len(country) == 0 {
+		resp, err = client.Countries()
+	} else if len(state) == 0 {
+		resp, err = client.States(country)
+	} else {
+		resp, err = client.Cities(state, country)
+	}
+	if err != nil {
+		return nil, err
 	}
 	if resp.Status != airvisual.StatusSuccess {
-		return -1, exception.New("RequestFailed").WithMessagef("%v", resp)
+		return nil, exception.New("RequestFailed").WithMessagef("%v", resp)
 	}
-	return resp.Data.Current.Pollution.AQI, nil
+	return resp.Names(), nil
+}
+
+func responseData(resp *airvisual.Response, err error) (*airvisual.Data, error) {
+	if err != nil {
+		return nil, err
+	}
+	if resp.Status != airvisual.StatusSuccess {
+		return nil, exception.New("RequestFailed").WithMessagef("%v", resp)
+	}
+	return &resp.Data, nil
 }
 
 // FetchAndSendAQIForConfig fetches aqi and sends it for the config
 func FetchAndSendAQIForConfig(c *config.Config, req *airvisual.LocationRequest, log *logger.Logger) (int, error) {
-	aqi, err := FetchAQI(c, req, log)
+	return FetchAndSendAQIForReport(c, DefaultReport(c), provider.CityLocation(req), log)
+}
+
+// FetchAndSendAQIForReport fetches aqi and sends it to the report's webhook and channel
+func FetchAndSendAQIForReport(c *config.Config, r config.Report, loc *provider.Location, log *logger.Logger) (int, error) {
+	data, err := FetchLocationAQI(c, loc, log)
 	if err != nil {
 		return -1, err
 	}
+	aqi := data.Current.Pollution.AQI
 	log.SyncInfof("AQI: `%d`", aqi)
 
-	channel := c.GetSlackChannel("slack-bot-test")
-	log.SyncInfof("Notifying slack channel `%s`", channel)
-	message := AQISlackMessage(aqi, req.City)
-	message.Channel = channel
-	return aqi, slack.Notify(c.SlackWebhook, message)
+	message, err := ReportSlackMessage(r, data, LocationName(loc, data), IndexFor(c, "", data))
+	if err != nil {
+		return aqi, err
+	}
+	log.SyncInfof("Notifying slack channel `%s`", r.Channel)
+	message.Channel = r.Channel
+	return aqi, slack.Notify(r.Webhook, message)
 }
@@ -0,0 +1,68 @@
+package util
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mat285/aqi/pkg/config"
+)
+
+func TestResolveLocation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aqi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	c := &config.Config{
+		AliasesFile: filepath.Join(dir, "aliases.json"),
+		Aliases:     map[string]string{"office": "94110", "cabin": "39.1,-120.1"},
+	}
+	cases := []struct {
+		text     string
+		location string
+		places   []string
+	}{
+		{"", "San Francisco, California, USA", nil},
+		{"sf", "San Francisco, California, USA", nil},
+		{"in nyc", "New York, New York, USA", nil},
+		{"IN  portland   oregon", "Portland, Oregon, USA", nil},
+		{"94110", "San Francisco, California, USA", nil},
+		{"office", "San Francisco, California, USA", nil},
+		{`city "Berlin" "Berlin" "Germany"`, "Berlin, Berlin, Germany", nil},
+		{"paris", "", []string{"Paris, Ile-de-France, France", "Paris, Texas, USA"}},
+		{"london", "", []string{"London, England, United Kingdom", "London, Ontario, Canada"}},
+		{"lasagna", "", nil},
+		{"99999", "", nil},
+	}
+	for _, c2 := range cases {
+		loc, places := ResolveLocation(c, c2.text)
+		if len(c2.location) == 0 && loc != nil {
+			t.Errorf("%q: resolved %v, expected no location", c2.text, loc)
+		}
+		if len(c2.location) > 0 && (loc == nil || fmt.Sprintf("%s, %s, %s", loc.City, loc.State, loc.Country) != c2.location) {
+			t.Errorf("%q: resolved %v, expected %s", c2.text, loc, c2.location)
+		}
+		if len(places) != len(c2.places) {
+			t.Errorf("%q: could be %v, expected %v", c2.text, places, c2.places)
+			continue
+		}
+		for i, p := range places {
+			if p.String() != c2.places[i] {
+				t.Errorf("%q: could be %v, expected %v", c2.text, places, c2.places)
+				break
+			}
+		}
+	}
+
+	loc, _ := ResolveLocation(c, "cabin")
+	if loc == nil || loc.Coordinates == nil || loc.Coordinates.Latitude != 39.1 || loc.Coordinates.Longitude != -120.1 || loc.HasCity() {
+		t.Errorf("expected an alias to coordinates to resolve to them, got %v", loc)
+	}
+	loc, _ = ResolveLocation(c, "sf")
+	if loc == nil || loc.Coordinates == nil {
+		t.Errorf("expected a place to resolve with its coordinates, got %v", loc)
+	}
+}
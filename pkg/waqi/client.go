@@ -0,0 +1,60 @@
+package waqi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	exception "github.com/blend/go-sdk/exception"
+	request "github.com/blend/go-sdk/request"
+)
+
+// Client is a waqi client
+type Client struct {
+	token string
+}
+
+// New returns a new waqi client
+func New(token string) *Client {
+	return &Client{
+		token: token,
+	}
+}
+
+// City returns the feed for the city
+func (c *Client) City(city string) (*Feed, error) {
+	if len(city) == 0 {
+		return nil, exception.New("MissingCity")
+	}
+	return c.feed(url.PathEscape(strings.ToLower(city)))
+}
+
+// Geo returns the feed for the station nearest the coordinates
+func (c *Client) Geo(lat, lon float64) (*Feed, error) {
+	return c.feed(fmt.Sprintf("geo:%v;%v", lat, lon))
+}
+
+func (c *Client) feed(path string) (*Feed, error) {
+	u, _ := url.Parse(FeedURL + path + "/")
+	v := url.Values{}
+	v.Set("token", c.token)
+	u.RawQuery = v.Encode()
+	resp := &Response{}
+	err := request.Get(u.String()).JSON(resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Feed()
+}
+
+// Feed returns the feed of a successful response
+func (r *Response) Feed() (*Feed, error) {
+	if r.Status != StatusOK {
+		var message string
+		json.Unmarshal(r.Data, &message)
+		return nil, exception.New("RequestFailed").WithMessage(message)
+	}
+	feed := &Feed{}
+	return feed, exception.New(json.Unmarshal(r.Data, feed))
+}
@@ -0,0 +1,53 @@
+package waqi
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func readResponse(t *testing.T, name string) *Response {
+	t.Helper()
+	data, err := ioutil.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := &Response{}
+	if err := json.Unmarshal(data, resp); err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestFeed(t *testing.T) {
+	feed, err := readResponse(t, "feed.json").Feed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if feed.AQI != 153 || feed.IDX != 3901 || feed.DominantPollutant != "pm25" || len(feed.City.Geo) != 2 || feed.City.Geo[0] != 37.7658 {
+		t.Errorf("unexpected feed %+v", feed)
+	}
+	if feed.IAQI["pm25"].V != 153 || feed.IAQI["t"].V != 24.1 {
+		t.Errorf("unexpected individual readings %+v", feed.IAQI)
+	}
+	if expected := time.Date(2020, time.September, 7, 19, 0, 0, 0, time.UTC); !feed.Time.ISO.Equal(expected) {
+		t.Errorf("read at %s, expected %s", feed.Time.ISO.UTC(), expected)
+	}
+}
+
+func TestFeedUnavailable(t *testing.T) {
+	feed, err := readResponse(t, "unavailable.json").Feed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if feed.AQI != -1 {
+		t.Errorf("expected an unavailable aqi to be -1, got %d", feed.AQI)
+	}
+}
+
+func TestFeedError(t *testing.T) {
+	if _, err := readResponse(t, "error.json").Feed(); err == nil {
+		t.Error("expected an error for a failed request")
+	}
+}
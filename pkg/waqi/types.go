@@ -0,0 +1,73 @@
+package waqi
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// Status is the status of a request
+type Status string
+
+const (
+	// StatusOK is the ok status
+	StatusOK Status = "ok"
+	// StatusError is the error status
+	StatusError Status = "error"
+)
+
+const (
+	// BaseURL is the base url for requests
+	BaseURL = "https://api.waqi.info/"
+	// FeedURL is the url for feed requests
+	FeedURL = BaseURL + "feed/"
+)
+
+// Response is a response from waqi, data is a feed on success and an error message on failure
+type Response struct {
+	Status Status          `json:"status"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// Feed is the current reading of a station
+type Feed struct {
+	AQI               Index            `json:"aqi"`
+	IDX               int              `json:"idx"`
+	City              City             `json:"city"`
+	DominantPollutant string           `json:"dominentpol"`
+	IAQI              map[string]Value `json:"iaqi"`
+	Time              Time             `json:"time"`
+}
+
+// City is the city of a station
+type City struct {
+	Name string    `json:"name"`
+	Geo  []float64 `json:"geo"`
+	URL  string    `json:"url"`
+}
+
+// Value is an individual reading, pollutants are reported as their us aqi
+type Value struct {
+	V float64 `json:"v"`
+}
+
+// Time is the time of the reading
+type Time struct {
+	S   string    `json:"s"`
+	TZ  string    `json:"tz"`
+	ISO time.Time `json:"iso"`
+}
+
+// Index is an aqi that waqi reports as "-" when it is unavailable
+type Index int
+
+// UnmarshalJSON implements json.Unmarshaler
+func (i *Index) UnmarshalJSON(data []byte) error {
+	v, err := strconv.Atoi(string(data))
+	if err != nil {
+		*i = -1
+		return nil
+	}
+	*i = Index(v)
+	return nil
+}
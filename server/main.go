@@ -4,22 +4,38 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/blend/go-sdk/env"
+	"github.com/blend/go-sdk/graceful"
 	logger "github.com/blend/go-sdk/logger"
 	web "github.com/blend/go-sdk/web"
+	"github.com/mat285/aqi/pkg/airvisual"
+	"github.com/mat285/aqi/pkg/command"
 	"github.com/mat285/aqi/pkg/config"
+	"github.com/mat285/aqi/pkg/history"
+	"github.com/mat285/aqi/pkg/index"
+	"github.com/mat285/aqi/pkg/prefs"
+	"github.com/mat285/aqi/pkg/provider"
 	"github.com/mat285/aqi/pkg/util"
-	slackserver "github.com/mat285/slack/server"
 	"github.com/mat285/slack/slack"
 )
 
 var (
-	conf *config.Config
-	log  *logger.Logger
+	conf    *config.Config
+	log     *logger.Logger
+	router  *command.Router
+	baseURL string
+	// secret is the slack signing secret, it also signs chart urls
+	secret   string
+	verifier *slack.Slack
 )
 
-const errMessage = "Oops! Something's not quite right"
+const (
+	errMessage = "Oops! Something's not quite right"
+	// pleaseWord lets blocked users through, it is dropped from the text before the command is parsed
+	pleaseWord = "please"
+)
 
 func main() {
 	log = logger.All()
@@ -28,17 +44,16 @@ func main() {
 	if err != nil {
 		log.SyncFatalExit(err)
 	}
-	c, err := config.NewFromEnv()
+	c, err := config.Load()
 	if err != nil {
 		log.SyncFatalExit(err)
 	}
 	conf = c
+	baseURL = wc.GetBaseURL()
+	router = newRouter()
 
-	sc := &slackserver.Config{
-		Config:               *wc,
-		AcknowledgeOnVerify:  false,
-		SlackSignatureSecret: env.Env().String(slack.EnvVarSignatureSecret),
-	}
+	secret = env.Env().String(slack.EnvVarSignatureSecret)
+	verifier = slack.New([]byte(secret))
 
 	file := env.Env().String("BLOCKED_USERS_FILE")
 	_, err = os.Stat(file)
@@ -49,30 +64,446 @@ func main() {
 		}
 	}
 
-	serv := slackserver.New(sc).WithHandler(handle)
-	err = serv.Start()
+	app := web.NewFromConfig(wc)
+	app.POST("/", handleSlashCommand)
+	app.GET("/healthz", handleHealthz)
+	app.GET(util.HistoryChartPath, handleChart)
+	err = graceful.Shutdown(app)
 	if err != nil {
 		log.SyncFatalExit(err)
 	}
 }
 
-func handle(sr *slack.SlashCommandRequest) (*slack.Message, error) {
-	user := sr.UserID
-	text := sr.Text
+// handleSlashCommand verifies the request came from slack and replies with the command's answer
+func handleSlashCommand(r *web.Ctx) web.Result {
+	sr, err := verifier.VerifyRequest(r.Request())
+	if err != nil {
+		return r.JSON().NotAuthorized()
+	}
+	m, err := respond(sr)
+	if err != nil {
+		log.Error(err)
+		m = util.TextSlackMessage(errMessage)
+		m.ResponseType = slack.ResponseTypeEphemeral
+	}
+	if m == nil {
+		return r.JSON().OK()
+	}
+	return r.JSON().Result(m)
+}
+
+func handleHealthz(r *web.Ctx) web.Result {
+	return r.JSON().OK()
+}
 
-	if util.IsBlocked(user) && !strings.Contains(text, "please") {
+// respond answers the request, acknowledging commands configured to be async right away
+// and posting their reply to the response url once it is ready
+func respond(sr *slack.SlashCommandRequest) (*slack.Message, error) {
+	c, _ := router.Lookup(sr.Text)
+	if len(sr.ResponseURL) == 0 || c == nil || !conf.IsAsyncCommand(c.Name) {
+		return handle(sr)
+	}
+	go func() {
+		message, err := handle(sr)
+		if err != nil {
+			log.Error(err)
+			message = util.TextSlackMessage(errMessage)
+			message.ResponseType = slack.ResponseTypeEphemeral
+		}
+		if message == nil {
+			return
+		}
+		err = util.Respond(sr.ResponseURL, message, log)
+		if err != nil {
+			log.Error(err)
+		}
+	}()
+	return util.AcknowledgeSlackMessage(), nil
+}
+
+func handle(sr *slack.SlashCommandRequest) (*slack.Message, error) {
+	if util.IsBlocked(sr.UserID) && !strings.Contains(strings.ToLower(sr.Text), pleaseWord) {
 		return util.BlockedSlackMessage(), nil
 	}
-	req := util.LocationRequestFromText(text)
+	m, err := router.Handle(sr)
+	if m != nil && util.UserPreferences(conf, sr.UserID).Private {
+		m.ResponseType = slack.ResponseTypeEphemeral
+	}
+	return m, err
+}
+
+var locationArg = command.Arg{
+	Name:        "location",
+	Description: "a city like `portland oregon` or `paris`, a zip code, an alias, `city \"<City>\" \"<State>\" \"<Country>\"` or `<lat>,<lon>`, your default location or San Francisco by default",
+	Optional:    true,
+	Variadic:    true,
+}
+
+func newRouter() *command.Router {
+	return command.NewRouter().
+		WithIgnored(pleaseWord).
+		WithMessage(util.TextSlackMessage).
+		WithDefault(&command.Command{
+			Name:    "aqi",
+			Summary: "Shows the current air quality for a location.",
+			Args:    []command.Arg{locationArg},
+			Flags: []command.Flag{
+				{Name: "index", Description: "the index to report in, `us`, `cn`, `caqi`, `daqi`, `naqi` or `local`"},
+			},
+			Handler: handleAQI,
+		}).
+		Add(&command.Command{
+			Name:    "cigarettes",
+			Summary: "Shows how many cigarettes breathing the air all day is equal to.",
+			Args:    []command.Arg{locationArg},
+			Handler: handleCigarettes,
+		}).
+		Add(&command.Command{
+			Name:    "forecast",
+			Summary: "Shows the predicted air quality for the next 24 hours and 3 days, requires an air visual plan with forecasts.",
+			Args:    []command.Arg{locationArg},
+			Handler: handleForecast,
+		}).
+		Add(&command.Command{
+			Name:    "history",
+			Summary: "Summarizes the recorded readings with the min, max and mean, hours in each health category, and the trend from the prior period.",
+			Args: []command.Arg{
+				locationArg,
+				{Name: "period", Description: "`24h`, `7d` or `30d`, 24 hours by default", Optional: true},
+			},
+			Handler: handleHistory,
+		}).
+		Add(&command.Command{
+			Name:    "station",
+			Summary: "Shows the current air quality at a monitoring station.",
+			Args: []command.Arg{
+				{Name: "station", Description: "`\"<Station>\" \"<City>\" \"<State>\" \"<Country>\"`, or `<lat>,<lon>` for the nearest station", Variadic: true},
+			},
+			Flags: []command.Flag{
+				{Name: "index", Description: "the index to report in, `us`, `cn`, `caqi`, `daqi`, `naqi` or `local`"},
+			},
+			Handler: handleStation,
+		}).
+		Add(&command.Command{
+			Name:    "locations",
+			Summary: "Lists the supported countries, the states in a country, or the cities in a state.",
+			Args: []command.Arg{
+				{Name: "country", Description: "the country to list the states of", Optional: true},
+				{Name: "state", Description: "the state to list the cities of", Optional: true},
+			},
+			Handler: handleLocations,
+		}).
+		Add(&command.Command{
+			Name:    "alias",
+			Summary: "Lists the short names for locations, or adds or removes one, which only admins can do.",
+			Args: []command.Arg{
+				{Name: "action", Description: "`list`, `add` or `remove`, list by default", Optional: true},
+				{Name: "name", Description: "the short name, like `hq`", Optional: true},
+				{Name: "location", Description: "the location the name is short for when adding, in any format a lookup takes", Optional: true, Variadic: true},
+			},
+			Handler: handleAlias,
+		}).
+		Add(&command.Command{
+			Name:    "set",
+			Summary: "Shows your preferences, or sets one, `default` clears it.",
+			Args: []command.Arg{
+				{Name: "preference", Description: "`location`, `index`, `units` or `replies`", Optional: true},
+				{Name: "value", Description: "a location, an index like `us`, `cn` or `local`, `c` or `f`, or `private` or `channel`", Optional: true, Variadic: true},
+			},
+			Handler: handleSet,
+		}).
+		Add(&command.Command{
+			Name:    "quota",
+			Summary: "Shows how many air visual calls have been made this minute and month.",
+			Handler: handleQuota,
+		})
+}
+
+// fetchLocation fetches the air data for the location text, returning the reply to send instead
+// if the location is unknown or ambiguous, or the fetch failed in a way users can act on
+func fetchLocation(ctx *command.Context, text string) (*airvisual.Data, string, *slack.Message, error) {
+	text = locationText(ctx, text)
+	loc, places := util.ResolveLocation(conf, text)
+	if len(places) > 0 {
+		return nil, "", util.AmbiguousLocationSlackMessage(text, places, commandText(ctx)), nil
+	}
+	if loc == nil {
+		return nil, "", util.UnknownLocationSlackMessage(text), nil
+	}
+	data, err := util.FetchLocationAQI(conf, loc, log)
+	if err != nil {
+		m, err := errorReply(err, loc, ctx.UserID())
+		return nil, "", m, err
+	}
+	if !loc.HasCity() {
+		return data, data.City, nil, nil
+	}
+	return data, loc.City, nil, nil
+}
+
+// locationText returns the location text, or the user's default location if there is no text
+func locationText(ctx *command.Context, text string) string {
+	if len(strings.TrimSpace(text)) > 0 {
+		return text
+	}
+	return util.UserPreferences(conf, ctx.UserID()).Location
+}
+
+// commandText returns the slash command and the subcommand if it was typed, to suggest running again
+func commandText(ctx *command.Context) string {
+	slashCommand := command.SlashCommand(ctx.Request)
+	if !router.Named(ctx.Request.Text) {
+		return slashCommand
+	}
+	return fmt.Sprintf("%s %s", slashCommand, ctx.Command.Name)
+}
+
+// indexFor returns the index to reply in, the index flag if it was given
+func indexFor(ctx *command.Context, data *airvisual.Data) index.Index {
+	if name, ok := ctx.Flag("index"); ok {
+		if i, ok := index.Parse(name); ok {
+			return i.Resolve(data.Country)
+		}
+	}
+	return util.IndexFor(conf, ctx.UserID(), data)
+}
+
+// errorReply returns a helpful reply for errors users can act on, with the last cached reading of the location
+// if there is a location when the airvisual budget is used up, and otherwise the error
+func errorReply(err error, loc *provider.Location, user string) (*slack.Message, error) {
+	if m, ok := util.ErrorSlackMessage(conf, err, loc, user); ok {
+		log.Error(err)
+		return m, nil
+	}
+	return nil, err
+}
+
+func handleAQI(ctx *command.Context) (*slack.Message, error) {
+	data, name, reply, err := fetchLocation(ctx, ctx.Rest(0))
+	if data == nil {
+		return reply, err
+	}
+	return util.UnitsIndexSlackMessage(data, name, indexFor(ctx, data), util.UserUnits(conf, ctx.UserID())), nil
+}
+
+func handleCigarettes(ctx *command.Context) (*slack.Message, error) {
+	data, name, reply, err := fetchLocation(ctx, ctx.Rest(0))
+	if data == nil {
+		return reply, err
+	}
+	return util.CigarettesSlackMessage(data, name), nil
+}
+
+func handleForecast(ctx *command.Context) (*slack.Message, error) {
+	data, name, reply, err := fetchLocation(ctx, ctx.Rest(0))
+	if data == nil {
+		return reply, err
+	}
+	return util.ForecastSlackMessage(data, name), nil
+}
+
+func handleStation(ctx *command.Context) (*slack.Message, error) {
+	args := ctx.Rest(0)
+	if lat, lon, ok := util.CoordinatesFromText(args); ok {
+		data, err := util.FetchNearestStationAQI(conf, lat, lon, log)
+		if err != nil {
+			return errorReply(err, nil, ctx.UserID())
+		}
+		return util.UnitsIndexSlackMessage(data, data.Name, indexFor(ctx, data), util.UserUnits(conf, ctx.UserID())), nil
+	}
+	req := util.StationAirVisualRequest(args)
 	if req == nil {
 		return nil, fmt.Errorf(errMessage)
 	}
-	aqi, err := util.FetchAQI(conf, req, log)
+	data, err := util.FetchStationAQI(conf, req, log)
+	if err != nil {
+		return errorReply(err, nil, ctx.UserID())
+	}
+	return util.UnitsIndexSlackMessage(data, req.Station, indexFor(ctx, data), util.UserUnits(conf, ctx.UserID())), nil
+}
+
+func handleHistory(ctx *command.Context) (*slack.Message, error) {
+	args := ctx.Args
+	period := history.DefaultPeriod
+	if len(args) > 0 {
+		if _, ok := history.ParsePeriod(args[len(args)-1]); ok {
+			period = strings.ToLower(args[len(args)-1])
+			args = args[:len(args)-1]
+		}
+	}
+	duration, _ := history.ParsePeriod(period)
+	text := locationText(ctx, command.Join(args))
+	loc, places := util.ResolveLocation(conf, text)
+	if len(places) > 0 {
+		return util.AmbiguousLocationSlackMessage(text, places, commandText(ctx)), nil
+	}
+	if !loc.HasCity() {
+		return util.UnknownLocationSlackMessage(text), nil
+	}
+	req := loc.LocationRequest()
+	summary, err := util.FetchHistory(conf, req, duration)
+	if err != nil {
+		return nil, err
+	}
+	chartURL := ""
+	if len(baseURL) > 0 && len(secret) > 0 {
+		chartURL = util.HistoryChartURL(secret, baseURL, summary.Location, period, time.Now())
+	}
+	return util.HistorySlackMessage(summary, req.City, period, chartURL), nil
+}
+
+func handleAlias(ctx *command.Context) (*slack.Message, error) {
+	action, name := strings.ToLower(ctx.Arg(0)), ctx.Arg(1)
+	if len(action) == 0 || action == "list" {
+		aliases, err := util.Aliases(conf)
+		if err != nil {
+			return nil, err
+		}
+		return util.AliasesSlackMessage(aliases), nil
+	}
+	if action != "add" && action != "remove" {
+		return ephemeral(fmt.Sprintf("Unknown action `%s`\n%s", action, ctx.Command.Help(command.SlashCommand(ctx.Request), false))), nil
+	}
+	if !conf.IsAdmin(ctx.UserID()) {
+		return ephemeral("Only admins can change aliases"), nil
+	}
+	if len(strings.TrimSpace(name)) == 0 {
+		return ephemeral(fmt.Sprintf("Missing the alias name\n%s", ctx.Command.Help(command.SlashCommand(ctx.Request), false))), nil
+	}
+	s, err := util.AliasStore(conf)
+	if err != nil {
+		return nil, err
+	}
+	if action == "remove" {
+		removed, err := s.Remove(name)
+		if err != nil {
+			return nil, err
+		}
+		if removed {
+			return ephemeral(fmt.Sprintf("Removed the alias `%s`", name)), nil
+		}
+		if _, ok := util.ConfigAlias(conf, name); ok {
+			return ephemeral(fmt.Sprintf("The alias `%s` is set in the config file, it can only be removed there", name)), nil
+		}
+		return ephemeral(fmt.Sprintf("There's no alias `%s`", name)), nil
+	}
+	if c, ok := router.Command(strings.Fields(name)[0]); ok {
+		return ephemeral(fmt.Sprintf("`%s` is the `%s` command, pick another name", name, c.Name)), nil
+	}
+	location := ctx.Rest(2)
+	if len(location) == 0 {
+		return ephemeral(fmt.Sprintf("Missing the location `%s` is short for\n%s", name, ctx.Command.Help(command.SlashCommand(ctx.Request), false))), nil
+	}
+	loc, places := util.ResolveLocationSpec(location)
+	if len(places) > 0 {
+		return util.AmbiguousLocationSlackMessage(location, places, fmt.Sprintf("%s alias add %s", command.SlashCommand(ctx.Request), name)), nil
+	}
+	if loc == nil {
+		return util.UnknownLocationSlackMessage(location), nil
+	}
+	err = s.Set(name, location)
+	if err != nil {
+		return nil, err
+	}
+	return ephemeral(fmt.Sprintf("`%s` is now short for %s", name, loc)), nil
+}
+
+func handleSet(ctx *command.Context) (*slack.Message, error) {
+	preference, value := strings.ToLower(ctx.Arg(0)), ctx.Rest(1)
+	s, err := util.PreferenceStore(conf)
+	if err != nil {
+		return nil, err
+	}
+	user := ctx.UserID()
+	if len(preference) == 0 {
+		return util.PreferencesSlackMessage(s.Get(user)), nil
+	}
+	if len(value) == 0 {
+		return ephemeral(fmt.Sprintf("Missing the value to set `%s` to\n%s", preference, ctx.Command.Help(command.SlashCommand(ctx.Request), false))), nil
+	}
+	reset := strings.EqualFold(value, "default")
+	var update func(*prefs.Preferences)
+	switch preference {
+	case "location":
+		location := ""
+		if !reset {
+			loc, places := util.ResolveLocation(conf, value)
+			if len(places) > 0 {
+				return util.AmbiguousLocationSlackMessage(value, places, fmt.Sprintf("%s set location", command.SlashCommand(ctx.Request))), nil
+			}
+			if loc == nil {
+				return util.UnknownLocationSlackMessage(value), nil
+			}
+			location = value
+		}
+		update = func(p *prefs.Preferences) { p.Location = location }
+	case "index":
+		i, ok := index.Parse(value)
+		if !ok && !reset {
+			return ephemeral(fmt.Sprintf("Unknown index `%s`, try `us`, `cn`, `caqi`, `daqi`, `naqi` or `local`", value)), nil
+		}
+		update = func(p *prefs.Preferences) { p.Index = string(i) }
+	case "units":
+		u, ok := util.ParseUnits(value)
+		if !ok && !reset {
+			return ephemeral(fmt.Sprintf("Unknown units `%s`, try `c` or `f`", value)), nil
+		}
+		update = func(p *prefs.Preferences) { p.Units = string(u) }
+	case "replies":
+		private := false
+		switch strings.ToLower(value) {
+		case "private", "ephemeral":
+			private = true
+		case "channel", "public", "default":
+		default:
+			return ephemeral(fmt.Sprintf("Unknown replies `%s`, try `private` or `channel`", value)), nil
+		}
+		update = func(p *prefs.Preferences) { p.Private = private }
+	default:
+		return ephemeral(fmt.Sprintf("Unknown preference `%s`\n%s", preference, ctx.Command.Help(command.SlashCommand(ctx.Request), false))), nil
+	}
+	p, err := s.Update(user, update)
 	if err != nil {
 		return nil, err
 	}
-	if strings.Contains(text, "cigarettes") {
-		return util.CigarettesSlackMessage(aqi, req.City), nil
+	return util.PreferencesSlackMessage(p), nil
+}
+
+func ephemeral(text string) *slack.Message {
+	m := util.TextSlackMessage(text)
+	m.ResponseType = slack.ResponseTypeEphemeral
+	return m
+}
+
+func handleQuota(ctx *command.Context) (*slack.Message, error) {
+	return util.QuotaUsageSlackMessage(conf)
+}
+
+func handleChart(r *web.Ctx) web.Result {
+	location, _ := r.QueryValue("location")
+	period, _ := r.QueryValue("period")
+	t, _ := r.QueryValue("t")
+	sig, _ := r.QueryValue("sig")
+	if !util.VerifyHistoryChartURL(secret, location, period, t, sig, time.Now()) {
+		return r.Text().NotAuthorized()
+	}
+	duration, ok := history.ParsePeriod(period)
+	if len(location) == 0 || !ok {
+		return r.Text().BadRequest(fmt.Errorf("location and period are required"))
+	}
+	data, err := util.HistoryChart(conf, location, duration)
+	if err != nil {
+		log.Error(err)
+		return r.Text().InternalError(err)
+	}
+	return r.RawWithContentType("image/png", data)
+}
+
+func handleLocations(ctx *command.Context) (*slack.Message, error) {
+	country, state := ctx.Arg(0), ctx.Arg(1)
+	names, err := util.FetchLocations(conf, country, state, log)
+	if err != nil {
+		return errorReply(err, nil, ctx.UserID())
 	}
-	return util.AQISlackMessage(aqi, req.City), nil
+	return util.LocationsSlackMessage(country, state, names), nil
 }